@@ -44,7 +44,7 @@ func main() {
 
 	lr := sqlite.NewLinkRepository(db)
 	cr := sqlite.NewClickRepository(db)
-	linkSvc := link.NewService(lr, cfg.App.SlugLength, cfg.App.CustomDomains)
+	linkSvc := link.NewService(lr, cfg.App.SlugLength, cfg.App.CustomDomains, nil, nil)
 	folderSvc := folder.NewService(sqlite.NewFolderRepository(db))
 	ctx := context.Background()
 
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	neturl "net/url"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // stats accept any IANA time zone even without system zoneinfo
 
 	"github.com/rs/zerolog"
 
@@ -13,8 +15,17 @@ import (
 	"github.com/aftaab/trelay/internal/config"
 	"github.com/aftaab/trelay/internal/core/analytics"
 	"github.com/aftaab/trelay/internal/core/auth"
+	"github.com/aftaab/trelay/internal/core/event"
+	"github.com/aftaab/trelay/internal/core/filewatch"
 	"github.com/aftaab/trelay/internal/core/folder"
+	"github.com/aftaab/trelay/internal/core/geoip"
 	"github.com/aftaab/trelay/internal/core/link"
+	"github.com/aftaab/trelay/internal/core/preview"
+	"github.com/aftaab/trelay/internal/core/qr"
+	"github.com/aftaab/trelay/internal/core/report"
+	"github.com/aftaab/trelay/internal/core/url"
+	"github.com/aftaab/trelay/internal/core/webhook"
+	"github.com/aftaab/trelay/internal/storage/cache"
 	"github.com/aftaab/trelay/internal/storage/sqlite"
 )
 
@@ -43,25 +54,96 @@ func main() {
 		logger.Fatal().Err(err).Msg("failed to run migrations")
 	}
 
+	// Background jobs stop when this context is cancelled on shutdown
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
 	// Initialize repositories
-	linkRepo := sqlite.NewLinkRepository(db)
+	linkRepo := cache.NewLinkRepository(sqlite.NewLinkRepository(db), cfg.App.LinkCacheSize, cfg.App.LinkCacheTTL)
 	clickRepo := sqlite.NewClickRepository(db)
+	configRepo := sqlite.NewConfigRepository(db)
 	folderRepo := sqlite.NewFolderRepository(db)
+	reportRepo := sqlite.NewReportRepository(db)
+	webhookRepo := sqlite.NewWebhookRepository(db)
+
+	// Destination screening
+	screener, err := newScreener(bgCtx, cfg, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize URL screening")
+	}
+
+	// Click location enrichment
+	geoDB, err := newGeoIP(bgCtx, cfg, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to load GeoIP database")
+	}
+	defer geoDB.Close()
+
+	channels, err := newChannelClassifier(bgCtx, cfg, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to load referrer channel lists")
+	}
+
+	bots, err := newBotClassifier(bgCtx, cfg, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to load bot signature lists")
+	}
+
+	qrGenerator, err := qr.NewGenerator(cfg.App.QRLogoFile)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to load QR logo")
+	}
 
 	// Initialize services
 	linkService := link.NewService(
 		linkRepo,
 		cfg.App.SlugLength,
 		cfg.App.CustomDomains,
+		screener,
+		preview.NewService(),
 	)
 
 	analyticsService := analytics.NewService(
 		clickRepo,
+		configRepo,
 		cfg.App.IPAnonymization,
 		cfg.App.AnalyticsEnabled,
+		geoDB,
+		channels,
+		bots,
 	)
 
 	folderService := folder.NewService(folderRepo)
+	reportService := report.NewService(reportRepo, linkRepo, folderRepo, analyticsService, cfg.App.ReportDir)
+	webhookService := webhook.NewService(webhookRepo, webhook.Options{
+		MaxAttempts:  cfg.App.WebhookMaxAttempts,
+		Concurrency:  cfg.App.WebhookConcurrency,
+		Timeout:      cfg.App.WebhookTimeout,
+		LogRetention: cfg.App.WebhookLogRetention,
+	})
+
+	linkService.SetHealthCheckConcurrency(cfg.App.LinkCheckConcurrency)
+	linkService.SetFolderRepository(folderRepo)
+	linkService.SetConfigRepository(configRepo)
+
+	// Services publish domain events on the bus; features that react to
+	// them subscribe here rather than being wired into each service.
+	events := event.NewBus()
+	linkService.SetEventBus(events)
+	analyticsService.SetEventBus(events)
+	folderService.SetEventBus(events)
+	webhookService.Subscribe(events)
+
+	analyticsService.SetConversionTracking(cfg.App.ConversionTracking)
+	analyticsService.StartIngester(cfg.App.ClickQueueSize, cfg.App.ClickBatchSize, cfg.App.ClickFlushInterval)
+
+	go linkRepo.RunFlusher(bgCtx, cfg.App.ClickCountFlushInterval)
+	go linkService.RunPreviewRefresher(bgCtx, cfg.App.PreviewRefreshInterval, cfg.App.PreviewMaxAge)
+	go linkService.RunHealthChecker(bgCtx, cfg.App.LinkCheckInterval)
+	go analyticsService.RunRetention(bgCtx, time.Duration(cfg.App.ClickRetentionDays)*24*time.Hour)
+	go reportService.RunScheduler(bgCtx, cfg.App.ReportCheckInterval)
+	go webhookService.RunDispatcher(bgCtx, cfg.App.WebhookPollInterval)
+	go linkService.RunExpiryWatcher(bgCtx, cfg.App.LinkExpiryCheckInterval)
 
 	// Hash API key for comparison
 	apiKeyHash := auth.HashAPIKey(cfg.Auth.APIKey)
@@ -74,7 +156,9 @@ func main() {
 		RateLimitPerMin: cfg.App.RateLimitPerMin,
 		Logger:          logger,
 		StaticDir:       cfg.App.StaticDir,
-	}, linkService, analyticsService, folderService)
+		BaseURL:         cfg.App.BaseURL,
+		QR:              qrGenerator,
+	}, linkService, analyticsService, folderService, reportService, webhookService)
 
 	// Initialize server
 	server := api.NewServer(api.ServerConfig{
@@ -83,6 +167,7 @@ func main() {
 		WriteTimeout:    cfg.Server.WriteTimeout,
 		ShutdownTimeout: cfg.Server.ShutdownTimeout,
 	}, router, logger)
+	server.OnShutdown(analyticsService.CloseClickStreams)
 
 	// Start server in goroutine
 	go func() {
@@ -102,6 +187,8 @@ func main() {
 	<-quit
 
 	// Graceful shutdown
+	stopBackground()
+
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
@@ -109,5 +196,125 @@ func main() {
 		logger.Error().Err(err).Msg("server shutdown error")
 	}
 
+	// Flush queued clicks and click counts once no more redirects can arrive.
+	if err := analyticsService.Shutdown(ctx); err != nil {
+		logger.Error().Err(err).Int("pending", analyticsService.IngestStats().QueueDepth).Msg("click queue not drained")
+	}
+	if err := linkRepo.Flush(ctx); err != nil {
+		logger.Error().Err(err).Msg("failed to flush click counts")
+	}
+
 	logger.Info().Msg("server stopped")
 }
+
+// newScreener builds the destination screening chain and starts reloading
+// blocklist files when they change on disk.
+func newScreener(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (*url.Screener, error) {
+	var stages []url.Stage
+	var blocklist *url.Blocklist
+
+	if len(cfg.App.URLBlocklistFiles) > 0 {
+		var err error
+		blocklist, err = url.NewBlocklist(cfg.App.URLBlocklistFiles...)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info().Int("entries", blocklist.Len()).Msg("loaded URL blocklists")
+		stages = append(stages, blocklist)
+	}
+
+	if cfg.App.URLScreenHomographs {
+		stages = append(stages, url.NewHomographStage())
+	}
+	if cfg.App.URLScreenResolve {
+		stages = append(stages, url.NewAddressStage(nil))
+	}
+
+	screener := url.NewScreener(cfg.App.URLScreenCacheTTL, stages...)
+
+	if blocklist != nil {
+		go filewatch.Watch(ctx, blocklist.Paths(), cfg.App.URLBlocklistReloadInterval, func() {
+			if err := blocklist.Reload(); err != nil {
+				logger.Error().Err(err).Msg("failed to reload URL blocklists")
+				return
+			}
+			screener.Purge()
+			logger.Info().Int("entries", blocklist.Len()).Msg("reloaded URL blocklists")
+		})
+	}
+
+	return screener, nil
+}
+
+// newGeoIP loads the GeoIP database, if configured, and reloads it when the
+// file is replaced on disk. It returns nil when no database is configured.
+func newGeoIP(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (*geoip.DB, error) {
+	if cfg.App.GeoIPDatabase == "" {
+		return nil, nil
+	}
+
+	db, err := geoip.Open(cfg.App.GeoIPDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	dbType, _ := db.Metadata()
+	logger.Info().Str("path", db.Path()).Str("type", dbType).Msg("loaded GeoIP database")
+
+	go filewatch.Watch(ctx, []string{db.Path()}, cfg.App.GeoIPReloadInterval, func() {
+		if err := db.Reload(); err != nil {
+			logger.Error().Err(err).Msg("failed to reload GeoIP database")
+			return
+		}
+		logger.Info().Str("path", db.Path()).Msg("reloaded GeoIP database")
+	})
+
+	return db, nil
+}
+
+// newChannelClassifier builds the referrer channel classifier, treating the
+// short link domains as internal, and reloads extra lists when they change.
+func newChannelClassifier(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (*analytics.ChannelClassifier, error) {
+	internal := append([]string{cfg.App.DefaultDomain}, cfg.App.CustomDomains...)
+	if base, err := neturl.Parse(cfg.App.BaseURL); err == nil {
+		internal = append(internal, base.Host)
+	}
+
+	channels, err := analytics.NewChannelClassifier(internal, cfg.App.ReferrerChannelFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(channels.Paths()) > 0 {
+		go filewatch.Watch(ctx, channels.Paths(), cfg.App.ReferrerChannelReloadInterval, func() {
+			if err := channels.Reload(); err != nil {
+				logger.Error().Err(err).Msg("failed to reload referrer channel lists")
+				return
+			}
+			logger.Info().Msg("reloaded referrer channel lists")
+		})
+	}
+
+	return channels, nil
+}
+
+// newBotClassifier builds the bot classifier and reloads extra signature
+// lists when they change.
+func newBotClassifier(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (*analytics.BotClassifier, error) {
+	bots, err := analytics.NewBotClassifier(cfg.App.BotSignatureFiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bots.Paths()) > 0 {
+		go filewatch.Watch(ctx, bots.Paths(), cfg.App.BotSignatureReloadInterval, func() {
+			if err := bots.Reload(); err != nil {
+				logger.Error().Err(err).Msg("failed to reload bot signature lists")
+				return
+			}
+			logger.Info().Msg("reloaded bot signature lists")
+		})
+	}
+
+	return bots, nil
+}
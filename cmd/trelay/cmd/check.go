@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aftaab/trelay/internal/cli"
+)
+
+var (
+	checkFolder int64
+	checkTags   []string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [slug...]",
+	Short: "Check link destinations for dead pages",
+	Long: `Probe link destinations now and record their health.
+
+With no arguments every live link is checked; use --folder or --tags to
+narrow the selection. Broken links can later be listed with
+"trelay list --broken".
+
+Examples:
+  trelay check my-link other-link
+  trelay check --folder 1
+  trelay check --tags campaign -o json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cli.GetClient()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		req := cli.CheckLinksRequest{
+			Slugs: args,
+			Tags:  checkTags,
+		}
+
+		if cmd.Flags().Changed("folder") {
+			req.FolderID = &checkFolder
+		}
+
+		results, err := client.CheckLinks(req)
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		return cli.PrintCheckResults(results, cli.OutputFormat(outputFormat))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().Int64VarP(&checkFolder, "folder", "f", 0, "Check links in folder ID")
+	checkCmd.Flags().StringSliceVar(&checkTags, "tags", nil, "Check links with tags (comma-separated)")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aftaab/trelay/internal/cli"
+)
+
+var (
+	clicksFormat  string
+	clicksPeriod  string
+	clicksChannel string
+	clicksFrom    string
+	clicksTo      string
+	clicksTZ      string
+	clicksFolder  int64
+	clicksTag     string
+	clicksBots    string
+)
+
+var clicksCmd = &cobra.Command{
+	Use:   "clicks [slug]",
+	Short: "Export raw clicks",
+	Long: `Export individual clicks of a link, or of all links when no slug is
+given, as CSV or NDJSON on standard output. Every page is fetched, so the
+output holds all matching clicks, oldest first.
+
+Examples:
+  trelay clicks my-link > clicks.csv
+  trelay clicks my-link --format ndjson --period week
+  trelay clicks --tag campaign --from 2024-03-01 --to 2024-03-31
+  trelay clicks --folder 1 --tz Europe/Berlin
+  trelay clicks my-link --bots include`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if clicksFormat != "csv" && clicksFormat != "ndjson" {
+			err := fmt.Errorf("invalid format %q (use csv or ndjson)", clicksFormat)
+			cli.Error(err.Error())
+			return err
+		}
+
+		client, err := cli.GetClient()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		opts := cli.StatsOptions{
+			Period:  clicksPeriod,
+			Channel: clicksChannel,
+			From:    clicksFrom,
+			To:      clicksTo,
+			TZ:      clicksTZ,
+			Bots:    clicksBots,
+		}
+
+		var slug string
+		if len(args) == 1 {
+			slug = args[0]
+		} else {
+			opts.Tag = clicksTag
+			if cmd.Flags().Changed("folder") {
+				opts.FolderID = &clicksFolder
+			}
+		}
+
+		var cursor string
+		for page := 0; ; page++ {
+			var buf bytes.Buffer
+			cursor, err = client.ExportClicks(slug, clicksFormat, opts, cursor, &buf)
+			if err != nil {
+				cli.Error(err.Error())
+				return err
+			}
+
+			// Each CSV page repeats the header; keep only the first.
+			data := buf.Bytes()
+			if clicksFormat == "csv" && page > 0 {
+				if i := bytes.IndexByte(data, '\n'); i >= 0 {
+					data = data[i+1:]
+				}
+			}
+			if _, err := os.Stdout.Write(data); err != nil {
+				return err
+			}
+
+			if cursor == "" {
+				return nil
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(clicksCmd)
+
+	clicksCmd.Flags().StringVar(&clicksFormat, "format", "csv", "Output format (csv, ndjson)")
+	clicksCmd.Flags().StringVar(&clicksPeriod, "period", "", "Only export clicks from the last day, week, month or year")
+	clicksCmd.Flags().StringVar(&clicksFrom, "from", "", "Only export clicks from this date (YYYY-MM-DD) or RFC 3339 time on")
+	clicksCmd.Flags().StringVar(&clicksTo, "to", "", "Only export clicks up to the end of this date, or this RFC 3339 time")
+	clicksCmd.Flags().StringVar(&clicksTZ, "tz", "", "Format timestamps in this IANA time zone (default UTC)")
+	clicksCmd.Flags().StringVar(&clicksChannel, "channel", "", "Only export clicks from a referrer channel (search, social, email, messaging, internal, direct, other)")
+	clicksCmd.Flags().Int64VarP(&clicksFolder, "folder", "f", 0, "Only export links in folder ID (without a slug)")
+	clicksCmd.Flags().StringVar(&clicksTag, "tag", "", "Only export links with tag (without a slug)")
+	clicksCmd.Flags().StringVar(&clicksBots, "bots", "", "Export bot clicks, which are left out by default (include, only)")
+}
@@ -19,6 +19,11 @@ var (
 	createTags     []string
 	createBulk     bool
 	createOneTime  bool
+
+	createNoTracking  bool
+	createNoReferrer  bool
+	createNoUserAgent bool
+	createHonorDNT    bool
 )
 
 var createCmd = &cobra.Command{
@@ -33,6 +38,7 @@ Examples:
   trelay create https://example.com --tags project,docs
   trelay create https://example.com --domain short.example.com
   trelay create https://example.com --one-time
+  trelay create https://example.com --no-referrer --honor-dnt
 
 Bulk create from stdin:
   cat urls.txt | trelay create --bulk
@@ -61,6 +67,7 @@ Bulk create from stdin:
 			TTLHours:  createTTL,
 			Tags:      createTags,
 			IsOneTime: createOneTime,
+			Privacy:   createPrivacy(),
 		}
 
 		link, err := client.CreateLink(req)
@@ -84,6 +91,21 @@ Bulk create from stdin:
 	},
 }
 
+// createPrivacy returns the privacy settings given as flags, or nil to
+// inherit the folder's defaults.
+func createPrivacy() *cli.AnalyticsPrivacy {
+	p := cli.AnalyticsPrivacy{
+		DisableTracking: createNoTracking,
+		DropReferrer:    createNoReferrer,
+		DropUserAgent:   createNoUserAgent,
+		HonorDNT:        createHonorDNT,
+	}
+	if p == (cli.AnalyticsPrivacy{}) {
+		return nil
+	}
+	return &p
+}
+
 func createBulkLinks(client *cli.Client) error {
 	scanner := bufio.NewScanner(os.Stdin)
 	created := 0
@@ -102,6 +124,7 @@ func createBulkLinks(client *cli.Client) error {
 			TTLHours:  createTTL,
 			Tags:      createTags,
 			IsOneTime: createOneTime,
+			Privacy:   createPrivacy(),
 		}
 
 		link, err := client.CreateLink(req)
@@ -140,4 +163,8 @@ func init() {
 	createCmd.Flags().StringSliceVar(&createTags, "tags", nil, "Tags for the link (comma-separated)")
 	createCmd.Flags().BoolVar(&createBulk, "bulk", false, "Read URLs from stdin (one per line)")
 	createCmd.Flags().BoolVar(&createOneTime, "one-time", false, "Create a one-time link (burns after first access)")
+	createCmd.Flags().BoolVar(&createNoTracking, "no-tracking", false, "Record no analytics for clicks on the link")
+	createCmd.Flags().BoolVar(&createNoReferrer, "no-referrer", false, "Record clicks without their referrer")
+	createCmd.Flags().BoolVar(&createNoUserAgent, "no-user-agent", false, "Record clicks without browser, OS or device")
+	createCmd.Flags().BoolVar(&createHonorDNT, "honor-dnt", false, "Record no clicks sent with Do Not Track or Global Privacy Control")
 }
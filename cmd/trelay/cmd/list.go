@@ -12,6 +12,7 @@ var (
 	listFolder int64
 	listLimit  int
 	listOffset int
+	listBroken bool
 )
 
 var listCmd = &cobra.Command{
@@ -24,6 +25,7 @@ Examples:
   trelay list --search example
   trelay list --tags project,docs
   trelay list --folder 1
+  trelay list --broken
   trelay list --limit 10 --offset 20`,
 	Aliases: []string{"ls"},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -38,6 +40,7 @@ Examples:
 			Tags:   listTags,
 			Limit:  listLimit,
 			Offset: listOffset,
+			Broken: listBroken,
 		}
 
 		if cmd.Flags().Changed("folder") {
@@ -62,4 +65,5 @@ func init() {
 	listCmd.Flags().Int64VarP(&listFolder, "folder", "f", 0, "Filter by folder ID")
 	listCmd.Flags().IntVarP(&listLimit, "limit", "l", 50, "Maximum number of results")
 	listCmd.Flags().IntVar(&listOffset, "offset", 0, "Offset for pagination")
+	listCmd.Flags().BoolVar(&listBroken, "broken", false, "Only show links whose destination failed its last check")
 }
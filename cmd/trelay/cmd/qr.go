@@ -23,7 +23,9 @@ var (
 var qrCmd = &cobra.Command{
 	Use:   "qr <slug>",
 	Short: "Generate QR code for a link",
-	Long: `Generate a QR code image for a shortened link.
+	Long: `Generate a QR code image for a shortened link. The code encodes the
+short URL with ?src=qr, so scans show up as the qr source in stats; the
+marker is not passed on to the destination.
 
 Examples:
   trelay qr my-link
@@ -49,14 +51,14 @@ Examples:
 			cli.Error(err.Error())
 			return err
 		}
-		shortURL := fmt.Sprintf("%s/%s", cfg.APIURL, link.Slug)
+		qrURL := fmt.Sprintf("%s/%s?src=qr", cfg.APIURL, link.Slug)
 
 		outputFile := qrOutput
 		if outputFile == "" {
 			outputFile = fmt.Sprintf("%s-qr.png", link.Slug)
 		}
 
-		if err := qrcode.WriteFile(shortURL, qrcode.Medium, qrSize, outputFile); err != nil {
+		if err := qrcode.WriteFile(qrURL, qrcode.Medium, qrSize, outputFile); err != nil {
 			cli.Error(fmt.Sprintf("Failed to generate QR code: %v", err))
 			return err
 		}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aftaab/trelay/internal/cli"
+)
+
+var (
+	reportName    string
+	reportSlug    string
+	reportFolder  int64
+	reportTag     string
+	reportCadence string
+	reportTZ      string
+	reportMetrics []string
+	reportFormat  string
+	reportWebhook string
+	reportDir     string
+	reportDryRun  bool
+)
+
+var reportsCmd = &cobra.Command{
+	Use:   "reports",
+	Short: "Manage scheduled stats reports",
+	Long: `Scheduled reports summarise the clicks of a link, folder, tag or the
+whole workspace for the previous day, week or month, and are posted to a
+webhook or written to the server's report directory (REPORT_DIR).
+
+Examples:
+  trelay reports list
+  trelay reports create "Weekly summary" --cadence weekly --webhook https://hooks.example.com/trelay
+  trelay reports create "Campaign" --tag campaign --cadence daily --format csv --dir campaign
+  trelay reports run 1 --dry-run`,
+}
+
+var reportsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List reports",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cli.GetClient()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		reports, err := client.ListReports()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		return cli.PrintReports(reports, cli.OutputFormat(outputFormat))
+	},
+}
+
+var reportsCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a report",
+	Long: `Create a scheduled report. Without --slug, --folder or --tag the report
+covers every link. Without --webhook it is written to the server's report
+directory, in the subdirectory given by --dir.
+
+Metrics: clicks, visitors, daily, top_links, referrers, channels,
+countries, devices (default: all).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cli.GetClient()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		req := cli.CreateReportRequest{
+			Name:      args[0],
+			Scope:     "workspace",
+			Cadence:   reportCadence,
+			Timezone:  reportTZ,
+			Metrics:   reportMetrics,
+			Format:    reportFormat,
+			Delivery:  "file",
+			Directory: reportDir,
+		}
+
+		switch {
+		case reportSlug != "":
+			req.Scope, req.Slug = "link", reportSlug
+		case cmd.Flags().Changed("folder"):
+			req.Scope, req.FolderID = "folder", &reportFolder
+		case reportTag != "":
+			req.Scope, req.Tag = "tag", reportTag
+		}
+
+		if reportWebhook != "" {
+			req.Delivery, req.WebhookURL = "webhook", reportWebhook
+		}
+
+		report, err := client.CreateReport(req)
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		if cli.OutputFormat(outputFormat) == cli.OutputFormatJSON {
+			return cli.PrintReports([]cli.Report{*report}, cli.OutputFormatJSON)
+		}
+		cli.Success(fmt.Sprintf("Created report %d, first run at %s", report.ID, report.NextRunAt))
+		return nil
+	},
+}
+
+var reportsUpdateCmd = &cobra.Command{
+	Use:   "update <id>",
+	Short: "Change a report's schedule, contents or delivery",
+	Long: `Change a report. Only the flags given are updated; --webhook switches
+delivery to the webhook and --dir to the report directory.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseReportID(args[0])
+		if err != nil {
+			return err
+		}
+
+		req := cli.UpdateReportRequest{}
+		flags := cmd.Flags()
+		if flags.Changed("name") {
+			req.Name = &reportName
+		}
+		if flags.Changed("cadence") {
+			req.Cadence = &reportCadence
+		}
+		if flags.Changed("tz") {
+			req.Timezone = &reportTZ
+		}
+		if flags.Changed("metrics") {
+			req.Metrics = reportMetrics
+		}
+		if flags.Changed("format") {
+			req.Format = &reportFormat
+		}
+		if flags.Changed("webhook") {
+			delivery := "webhook"
+			req.Delivery, req.WebhookURL = &delivery, &reportWebhook
+		} else if flags.Changed("dir") {
+			delivery := "file"
+			req.Delivery, req.Directory = &delivery, &reportDir
+		}
+
+		return updateReport(id, req, "Updated")
+	},
+}
+
+var reportsPauseCmd = &cobra.Command{
+	Use:   "pause <id>",
+	Short: "Stop a report from running on schedule",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseReportID(args[0])
+		if err != nil {
+			return err
+		}
+		enabled := false
+		return updateReport(id, cli.UpdateReportRequest{Enabled: &enabled}, "Paused")
+	},
+}
+
+var reportsResumeCmd = &cobra.Command{
+	Use:   "resume <id>",
+	Short: "Run a paused report on schedule again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseReportID(args[0])
+		if err != nil {
+			return err
+		}
+		enabled := true
+		return updateReport(id, cli.UpdateReportRequest{Enabled: &enabled}, "Resumed")
+	},
+}
+
+var reportsDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a report",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseReportID(args[0])
+		if err != nil {
+			return err
+		}
+
+		client, err := cli.GetClient()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		if err := client.DeleteReport(id); err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		cli.Success(fmt.Sprintf("Deleted report %d", id))
+		return nil
+	},
+}
+
+var reportsRunCmd = &cobra.Command{
+	Use:   "run <id>",
+	Short: "Deliver a report now",
+	Long: `Render and deliver a report for its last whole period now, without
+changing its schedule. With --dry-run the report is printed instead of
+delivered.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseReportID(args[0])
+		if err != nil {
+			return err
+		}
+
+		client, err := cli.GetClient()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		if reportDryRun {
+			if err := client.PreviewReport(id, os.Stdout); err != nil {
+				cli.Error(err.Error())
+				return err
+			}
+			return nil
+		}
+
+		run, err := client.RunReport(id)
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		if run.Error != "" {
+			err := fmt.Errorf("report %d failed: %s", id, run.Error)
+			cli.Error(err.Error())
+			return err
+		}
+
+		cli.Success(fmt.Sprintf("Delivered report %d (%s to %s) to %s", id, run.From, run.To, run.Location))
+		return nil
+	},
+}
+
+func updateReport(id int64, req cli.UpdateReportRequest, verb string) error {
+	client, err := cli.GetClient()
+	if err != nil {
+		cli.Error(err.Error())
+		return err
+	}
+
+	report, err := client.UpdateReport(id, req)
+	if err != nil {
+		cli.Error(err.Error())
+		return err
+	}
+
+	if cli.OutputFormat(outputFormat) == cli.OutputFormatJSON {
+		return cli.PrintReports([]cli.Report{*report}, cli.OutputFormatJSON)
+	}
+	cli.Success(fmt.Sprintf("%s report %d", verb, report.ID))
+	return nil
+}
+
+func parseReportID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("invalid report ID %q", arg)
+		cli.Error(err.Error())
+	}
+	return id, err
+}
+
+func init() {
+	rootCmd.AddCommand(reportsCmd)
+	reportsCmd.AddCommand(reportsListCmd, reportsCreateCmd, reportsUpdateCmd, reportsPauseCmd, reportsResumeCmd, reportsDeleteCmd, reportsRunCmd)
+
+	for _, c := range []*cobra.Command{reportsCreateCmd, reportsUpdateCmd} {
+		c.Flags().StringVar(&reportCadence, "cadence", "weekly", "How often the report runs (daily, weekly, monthly)")
+		c.Flags().StringVar(&reportTZ, "tz", "", "IANA time zone periods are counted in (default UTC)")
+		c.Flags().StringSliceVar(&reportMetrics, "metrics", nil, "Metrics to include (comma-separated, default all)")
+		c.Flags().StringVar(&reportFormat, "format", "markdown", "Report format (json, csv, markdown)")
+		c.Flags().StringVar(&reportWebhook, "webhook", "", "POST the report to this URL")
+		c.Flags().StringVar(&reportDir, "dir", "", "Subdirectory of the server's report directory to write to")
+	}
+
+	reportsCreateCmd.Flags().StringVar(&reportSlug, "slug", "", "Report on one link")
+	reportsCreateCmd.Flags().Int64VarP(&reportFolder, "folder", "f", 0, "Report on the links in folder ID")
+	reportsCreateCmd.Flags().StringVar(&reportTag, "tag", "", "Report on the links with tag")
+
+	reportsUpdateCmd.Flags().StringVar(&reportName, "name", "", "Rename the report")
+
+	reportsRunCmd.Flags().BoolVar(&reportDryRun, "dry-run", false, "Print the report instead of delivering it")
+}
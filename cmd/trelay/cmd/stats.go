@@ -7,19 +7,37 @@ import (
 )
 
 var (
-	statsExport string
+	statsExport  string
+	statsPeriod  string
+	statsChannel string
+	statsFolder  int64
+	statsTag     string
+	statsFrom    string
+	statsTo      string
+	statsTZ      string
+	statsCompare string
+	statsBots    string
 )
 
 var statsCmd = &cobra.Command{
-	Use:   "stats <slug>",
+	Use:   "stats [slug]",
 	Short: "View link statistics",
-	Long: `View click statistics for a link.
+	Long: `View click statistics for a link, or across all links when no slug
+is given. Use --folder or --tag to narrow the overview.
 
 Examples:
+  trelay stats
+  trelay stats --folder 1
+  trelay stats --tag campaign --period month
   trelay stats my-link
   trelay stats my-link -o json
-  trelay stats my-link --export csv`,
-	Args: cobra.ExactArgs(1),
+  trelay stats my-link --export csv
+  trelay stats my-link --period week
+  trelay stats my-link --from 2024-03-01 --to 2024-03-31 --tz Europe/Berlin
+  trelay stats my-link --channel social
+  trelay stats my-link --bots only
+  trelay stats my-link --period week --compare previous`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client, err := cli.GetClient()
 		if err != nil {
@@ -27,10 +45,14 @@ Examples:
 			return err
 		}
 
-		stats, err := client.GetStats(args[0])
-		if err != nil {
-			cli.Error(err.Error())
-			return err
+		opts := cli.StatsOptions{
+			Period:  statsPeriod,
+			Channel: statsChannel,
+			From:    statsFrom,
+			To:      statsTo,
+			TZ:      statsTZ,
+			Compare: statsCompare,
+			Bots:    statsBots,
 		}
 
 		format := cli.OutputFormat(outputFormat)
@@ -38,6 +60,26 @@ Examples:
 			format = cli.OutputFormat(statsExport)
 		}
 
+		if len(args) == 0 {
+			opts.Tag = statsTag
+			if cmd.Flags().Changed("folder") {
+				opts.FolderID = &statsFolder
+			}
+
+			stats, err := client.GetWorkspaceStats(opts)
+			if err != nil {
+				cli.Error(err.Error())
+				return err
+			}
+			return cli.PrintWorkspaceStats(stats, format)
+		}
+
+		stats, err := client.GetStats(args[0], opts)
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
 		return cli.PrintStats(stats, format)
 	},
 }
@@ -46,4 +88,13 @@ func init() {
 	rootCmd.AddCommand(statsCmd)
 
 	statsCmd.Flags().StringVar(&statsExport, "export", "", "Export format (json, csv)")
+	statsCmd.Flags().StringVar(&statsPeriod, "period", "", "Only count clicks from the last day, week, month or year")
+	statsCmd.Flags().StringVar(&statsFrom, "from", "", "Only count clicks from this date (YYYY-MM-DD) or RFC 3339 time on")
+	statsCmd.Flags().StringVar(&statsTo, "to", "", "Only count clicks up to the end of this date, or this RFC 3339 time")
+	statsCmd.Flags().StringVar(&statsTZ, "tz", "", "Group clicks by day in this IANA time zone (default UTC)")
+	statsCmd.Flags().StringVar(&statsCompare, "compare", "", "Compare a link's --period or --from range with the previous window or the year before (previous, year)")
+	statsCmd.Flags().Int64VarP(&statsFolder, "folder", "f", 0, "Overview of links in folder ID (without a slug)")
+	statsCmd.Flags().StringVar(&statsTag, "tag", "", "Overview of links with tag (without a slug)")
+	statsCmd.Flags().StringVar(&statsChannel, "channel", "", "Only count clicks from a referrer channel (search, social, email, messaging, internal, direct, other)")
+	statsCmd.Flags().StringVar(&statsBots, "bots", "", "Count bot clicks, which are left out by default (include, only)")
 }
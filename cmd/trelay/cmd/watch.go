@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aftaab/trelay/internal/cli"
+)
+
+var (
+	watchFolder int64
+	watchTag    string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [slug]",
+	Short: "Tail clicks as they happen",
+	Long: `Print clicks as the server records them, until interrupted.
+
+With no slug every link is watched; use --folder or --tag to narrow the
+stream. With -o json each click is printed as one JSON object per line.
+
+Examples:
+  trelay watch
+  trelay watch my-link
+  trelay watch --tag campaign -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cli.GetClient()
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+
+		opts := cli.WatchOptions{Tag: watchTag}
+		if len(args) == 1 {
+			opts.Slug = args[0]
+		}
+		if cmd.Flags().Changed("folder") {
+			opts.FolderID = &watchFolder
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		format := cli.OutputFormat(outputFormat)
+		if format != cli.OutputFormatJSON {
+			fmt.Fprintln(os.Stderr, "Watching for clicks (Ctrl+C to stop)...")
+		}
+
+		err = client.WatchClicks(ctx, opts, func(click cli.LiveClick) error {
+			return cli.PrintLiveClick(click, format)
+		})
+		if err != nil {
+			cli.Error(err.Error())
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().Int64VarP(&watchFolder, "folder", "f", 0, "Only watch links in folder ID")
+	watchCmd.Flags().StringVar(&watchTag, "tag", "", "Only watch links with tag")
+}
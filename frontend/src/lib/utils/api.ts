@@ -216,6 +216,19 @@ export const importLinks = {
 		api.post<ImportResult>('/import/json', { links, skip_duplicates: skipDuplicates })
 };
 
+// Print-ready QR codes rendered by the server, on the link's custom domain
+export const qrCodes = {
+	download: async (slug: string, format: 'png' | 'svg' = 'svg', size: number = 1024) => {
+		const apiKey = getApiKey();
+		const params = new URLSearchParams({ format, size: String(size) });
+		const res = await fetch(`${API_BASE}/links/${encodeURIComponent(slug)}/qr?${params}`, {
+			headers: apiKey ? { 'X-API-Key': apiKey } : {}
+		});
+		if (!res.ok) throw new Error('Failed to render QR code');
+		return res.blob();
+	}
+};
+
 export const exportLinks = {
 	csv: async () => {
 		const apiKey = getApiKey();
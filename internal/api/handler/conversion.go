@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aftaab/trelay/internal/api/response"
+	"github.com/aftaab/trelay/internal/core/analytics"
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// transparentGIF is a 1x1 transparent GIF returned by the conversion pixel.
+var transparentGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+type ConversionHandler struct {
+	analyticsService *analytics.Service
+}
+
+func NewConversionHandler(analyticsService *analytics.Service) *ConversionHandler {
+	return &ConversionHandler{analyticsService: analyticsService}
+}
+
+// RecordConversionRequest reports a conversion for the click whose token
+// was appended to the destination URL.
+type RecordConversionRequest struct {
+	Click string `json:"click"`
+	Goal  string `json:"goal,omitempty"`
+}
+
+// Record handles POST /api/v1/conversions from the destination's frontend
+// or backend.
+func (h *ConversionHandler) Record(w http.ResponseWriter, r *http.Request) {
+	var req RecordConversionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	conv, err := h.analyticsService.RecordConversion(r.Context(), req.Click, req.Goal)
+	if err != nil {
+		if ve, ok := err.(domain.ValidationError); ok {
+			response.ValidationError(w, ve.Field, ve.Message)
+			return
+		}
+		if err == domain.ErrClickNotFound {
+			response.NotFound(w, "click not found")
+			return
+		}
+		response.InternalError(w)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, conv)
+}
+
+// Pixel handles GET /api/v1/conversions/pixel.gif?click=&goal= for pages
+// that can only embed an image. It always serves the image, so a broken
+// token never shows up on the destination page.
+func (h *ConversionHandler) Pixel(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	_, _ = h.analyticsService.RecordConversion(r.Context(), query.Get("click"), query.Get("goal"))
+
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store, max-age=0")
+	w.WriteHeader(http.StatusOK)
+	w.Write(transparentGIF)
+}
@@ -75,6 +75,39 @@ func (h *FolderHandler) Get(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, f)
 }
 
+// Update renames a folder or changes the privacy defaults new links in it
+// inherit.
+func (h *FolderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid folder ID")
+		return
+	}
+
+	var req domain.UpdateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	f, err := h.service.Update(r.Context(), id, req)
+	if err != nil {
+		if ve, ok := err.(domain.ValidationError); ok {
+			response.ValidationError(w, ve.Field, ve.Message)
+			return
+		}
+		if err == domain.ErrFolderNotFound {
+			response.NotFound(w, "folder not found")
+			return
+		}
+		response.InternalError(w)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, f)
+}
+
 func (h *FolderHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
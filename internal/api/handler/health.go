@@ -4,23 +4,29 @@ import (
 	"net/http"
 
 	"github.com/aftaab/trelay/internal/api/response"
+	"github.com/aftaab/trelay/internal/core/analytics"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	analyticsService *analytics.Service
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(analyticsService *analytics.Service) *HealthHandler {
+	return &HealthHandler{analyticsService: analyticsService}
 }
 
 type healthResponse struct {
-	Status  string `json:"status"`
-	Version string `json:"version"`
+	Status     string                 `json:"status"`
+	Version    string                 `json:"version"`
+	ClickQueue *analytics.IngestStats `json:"click_queue,omitempty"`
 }
 
 func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	stats := h.analyticsService.IngestStats()
 	response.JSON(w, http.StatusOK, healthResponse{
-		Status:  "ok",
-		Version: "2.0.0",
+		Status:     "ok",
+		Version:    "2.0.0",
+		ClickQueue: &stats,
 	})
 }
 
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -243,6 +244,34 @@ func (h *LinkHandler) BulkRestore(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, result)
 }
 
+// Check probes link destinations on demand and records their health.
+func (h *LinkHandler) Check(w http.ResponseWriter, r *http.Request) {
+	var req domain.CheckLinksRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.BadRequest(w, "invalid request body")
+			return
+		}
+	}
+
+	if len(req.Slugs) > domain.MaxLinkChecks {
+		response.ValidationError(w, "slugs", fmt.Sprintf("maximum %d slugs per request", domain.MaxLinkChecks))
+		return
+	}
+	if req.Offset < 0 {
+		response.ValidationError(w, "offset", "offset must not be negative")
+		return
+	}
+
+	results, err := h.service.CheckLinks(r.Context(), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, results)
+}
+
 func (h *LinkHandler) List(w http.ResponseWriter, r *http.Request) {
 	filter := domain.ListLinksFilter{
 		Search: r.URL.Query().Get("search"),
@@ -283,6 +312,11 @@ func (h *LinkHandler) List(w http.ResponseWriter, r *http.Request) {
 		filter.HasExpiry = &b
 	}
 
+	if v := r.URL.Query().Get("broken"); v != "" {
+		b := v == "true"
+		filter.Broken = &b
+	}
+
 	links, err := h.service.List(r.Context(), filter)
 	if err != nil {
 		h.handleError(w, err)
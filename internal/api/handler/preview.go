@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/aftaab/trelay/internal/api/response"
+	"github.com/aftaab/trelay/internal/core/netguard"
 	"github.com/aftaab/trelay/internal/core/preview"
 )
 
@@ -28,6 +29,10 @@ func (h *PreviewHandler) Fetch(w http.ResponseWriter, r *http.Request) {
 
 	previewData, err := h.service.Fetch(r.Context(), url)
 	if err != nil {
+		if netguard.IsBlocked(err) {
+			response.Error(w, http.StatusBadRequest, "destination_blocked", "this URL cannot be fetched")
+			return
+		}
 		response.Error(w, http.StatusBadGateway, "fetch_failed", "failed to fetch preview")
 		return
 	}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/aftaab/trelay/internal/api/response"
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/link"
+	"github.com/aftaab/trelay/internal/core/qr"
+)
+
+type QRHandler struct {
+	linkService *link.Service
+	generator   *qr.Generator
+	baseURL     string
+}
+
+// NewQRHandler creates a QR code handler. baseURL is the public URL short
+// links are served under when they have no custom domain.
+func NewQRHandler(linkService *link.Service, generator *qr.Generator, baseURL string) *QRHandler {
+	return &QRHandler{
+		linkService: linkService,
+		generator:   generator,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Get handles GET /api/v1/links/{slug}/qr, rendering the link's QR code as
+// PNG or SVG.
+func (h *QRHandler) Get(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		response.BadRequest(w, "slug is required")
+		return
+	}
+
+	// The code only carries the short URL, and the caller owns the link,
+	// so a protected link's visitor password is not asked for.
+	query := r.URL.Query()
+	linkData, err := h.linkService.GetLive(r.Context(), slug)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	opts, err := parseQROptions(query)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	image, err := h.generator.Render(h.shortURL(linkData), opts)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if download, _ := strconv.ParseBool(query.Get("download")); download {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-qr.%s", linkData.Slug, opts.Format))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(image)
+}
+
+// shortURL returns the URL a link's QR code encodes: the short URL on the
+// link's custom domain, or else on the base URL, marked as a QR scan.
+func (h *QRHandler) shortURL(link *domain.Link) string {
+	base := h.baseURL
+	if link.Domain != "" {
+		scheme := "https"
+		if u, err := url.Parse(h.baseURL); err == nil && u.Scheme != "" {
+			scheme = u.Scheme
+		}
+		base = scheme + "://" + link.Domain
+	}
+	return base + "/" + url.PathEscape(link.Slug) + "?" + domain.SourceParam + "=" + domain.SourceQR
+}
+
+func parseQROptions(query url.Values) (qr.Options, error) {
+	opts := qr.DefaultOptions()
+
+	if format := query.Get("format"); format != "" {
+		opts.Format = qr.Format(strings.ToLower(format))
+	}
+
+	if sizeStr := query.Get("size"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return opts, domain.NewValidationError("size", "size must be a number of pixels")
+		}
+		opts.Size = size
+	}
+
+	if marginStr := query.Get("margin"); marginStr != "" {
+		margin, err := strconv.Atoi(marginStr)
+		if err != nil {
+			return opts, domain.NewValidationError("margin", "margin must be a number of modules")
+		}
+		opts.Margin = &margin
+	}
+
+	opts.Level = strings.ToLower(query.Get("level"))
+
+	if fg := query.Get("fg"); fg != "" {
+		c, err := qr.ParseColor(fg)
+		if err != nil {
+			return opts, domain.NewValidationError("fg", "fg must be a hex colour such as 000000 or #1a2b3c")
+		}
+		opts.Foreground = c
+	}
+
+	if bg := query.Get("bg"); bg != "" {
+		c, err := qr.ParseColor(bg)
+		if err != nil {
+			return opts, domain.NewValidationError("bg", "bg must be a hex colour such as ffffff or #ffffff00")
+		}
+		opts.Background = c
+	}
+
+	if logoStr := query.Get("logo"); logoStr != "" {
+		logo, err := strconv.ParseBool(logoStr)
+		if err != nil {
+			return opts, domain.NewValidationError("logo", "logo must be true or false")
+		}
+		opts.Logo = logo
+	}
+
+	return opts, nil
+}
+
+func (h *QRHandler) handleError(w http.ResponseWriter, err error) {
+	switch err {
+	case domain.ErrLinkNotFound, domain.ErrLinkDeleted:
+		response.NotFound(w, "link not found")
+	case domain.ErrLinkExpired:
+		response.Error(w, http.StatusGone, "link_expired", "this link has expired")
+	default:
+		if ve, ok := err.(domain.ValidationError); ok {
+			response.ValidationError(w, ve.Field, ve.Message)
+			return
+		}
+		response.InternalError(w)
+	}
+}
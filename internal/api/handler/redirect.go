@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"html"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
@@ -96,8 +97,7 @@ func (h *RedirectHandler) handleRedirect(w http.ResponseWriter, r *http.Request,
 		if linkData.IsOneTime {
 			_ = h.linkService.Burn(r.Context(), linkData.ID)
 		}
-		h.recordAnalyticsAsync(r, linkData.ID)
-		http.Redirect(w, r, linkData.OriginalURL, http.StatusMovedPermanently)
+		h.redirect(w, r, linkData)
 		return
 	}
 
@@ -106,7 +106,7 @@ func (h *RedirectHandler) handleRedirect(w http.ResponseWriter, r *http.Request,
 			response.Error(w, http.StatusUnauthorized, "password_required", "this link requires a password")
 			return
 		}
-		h.writePasswordPage(w, slug, false)
+		h.writePasswordPage(w, r, slug, false)
 		return
 	}
 
@@ -117,7 +117,7 @@ func (h *RedirectHandler) handleRedirect(w http.ResponseWriter, r *http.Request,
 				response.Error(w, http.StatusUnauthorized, "password_incorrect", "incorrect password")
 				return
 			}
-			h.writePasswordPage(w, slug, true)
+			h.writePasswordPage(w, r, slug, true)
 			return
 		}
 		h.handleError(w, err)
@@ -130,20 +130,78 @@ func (h *RedirectHandler) handleRedirect(w http.ResponseWriter, r *http.Request,
 		_ = h.linkService.Burn(r.Context(), linkData.ID)
 	}
 
-	h.recordAnalyticsAsync(r, linkData.ID)
-	http.Redirect(w, r, linkData.OriginalURL, http.StatusMovedPermanently)
+	h.redirect(w, r, linkData)
 }
 
-func (h *RedirectHandler) recordAnalyticsAsync(r *http.Request, linkID int64) {
-	go func() {
-		if analytics.IsBot(r.UserAgent()) {
-			return
+// redirect records the click and sends the visitor on. A destination
+// carrying a conversion token is only good for this click, so it is sent
+// as a 302 that browsers will not cache and replay.
+func (h *RedirectHandler) redirect(w http.ResponseWriter, r *http.Request, link *domain.Link) {
+	target := h.recordAnalytics(r, link)
+	status := http.StatusMovedPermanently
+	if target != link.OriginalURL {
+		status = http.StatusFound
+	}
+	http.Redirect(w, r, target, status)
+}
+
+// recordAnalytics queues the click for the analytics batch writer and
+// returns the URL to redirect to, which carries the click's conversion
+// token when conversion tracking is on. Bot hits are queued too, so they
+// are classified rather than lost from the link's click count. It never
+// blocks the redirect; clicks are dropped if the queue is full.
+func (h *RedirectHandler) recordAnalytics(r *http.Request, link *domain.Link) string {
+	ev := analytics.ClickEvent{
+		Link:       link,
+		IP:         getClientIP(r),
+		UserAgent:  r.UserAgent(),
+		Referrer:   r.Referer(),
+		DoNotTrack: r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1",
+		Source:     clickSource(r),
+	}
+	ev.Token = h.analyticsService.ClickToken(ev)
+	if !h.analyticsService.Enqueue(ev) || ev.Token == "" {
+		return link.OriginalURL
+	}
+	return withQueryParam(link.OriginalURL, domain.ConversionTokenParam, ev.Token)
+}
+
+// clickSource tells how a short link was opened: generated QR codes carry
+// src=qr, programs ask for JSON or send src=api, and anything else is a
+// direct visit. The marker only lives on the short URL; destinations never
+// see it.
+func clickSource(r *http.Request) string {
+	switch r.URL.Query().Get(domain.SourceParam) {
+	case domain.SourceQR:
+		return domain.SourceQR
+	case domain.SourceAPI:
+		return domain.SourceAPI
+	}
+	if wantsRedirectJSON(r) {
+		return domain.SourceAPI
+	}
+	return domain.SourceDirect
+}
+
+// withQueryParam appends key=value to rawURL's query, before any fragment,
+// leaving the rest of the URL exactly as it was.
+func withQueryParam(rawURL, key, value string) string {
+	fragment := ""
+	if i := strings.Index(rawURL, "#"); i != -1 {
+		rawURL, fragment = rawURL[:i], rawURL[i:]
+	}
+
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+		if strings.HasSuffix(rawURL, "?") || strings.HasSuffix(rawURL, "&") {
+			sep = ""
 		}
-		_ = h.analyticsService.RecordClick(r.Context(), linkID, getClientIP(r), r.UserAgent(), r.Referer())
-	}()
+	}
+	return rawURL + sep + url.QueryEscape(key) + "=" + url.QueryEscape(value) + fragment
 }
 
-func (h *RedirectHandler) writePasswordPage(w http.ResponseWriter, slug string, wrongPassword bool) {
+func (h *RedirectHandler) writePasswordPage(w http.ResponseWriter, r *http.Request, slug string, wrongPassword bool) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusUnauthorized)
 
@@ -153,7 +211,13 @@ func (h *RedirectHandler) writePasswordPage(w http.ResponseWriter, slug string,
 		errMsg = `<p class="err">Incorrect password. Try again.</p>`
 	}
 
+	// The form posts back with the source marker, so a QR scan of a
+	// protected link is still attributed once the password is entered.
 	escSlug := html.EscapeString(slug)
+	action := "/" + escSlug
+	if source := clickSource(r); source != domain.SourceDirect {
+		action += "?" + domain.SourceParam + "=" + source
+	}
 	fmt.Fprintf(w, `<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -179,7 +243,7 @@ button:hover{background:#2ea043;}
 <h1>Protected link</h1>
 <p class="sub">/%s requires a password to continue.</p>
 %s
-<form method="post" action="/%s" autocomplete="current-password">
+<form method="post" action="%s" autocomplete="current-password">
 <label for="password">Password</label>
 <input id="password" name="password" type="password" required autofocus/>
 <button type="submit">Continue</button>
@@ -187,7 +251,7 @@ button:hover{background:#2ea043;}
 <p class="hint">You can still open this link with <code>?p=…</code> in the URL if you prefer.</p>
 </div>
 </body>
-</html>`, title, escSlug, errMsg, escSlug)
+</html>`, title, escSlug, errMsg, action)
 }
 
 func (h *RedirectHandler) handleError(w http.ResponseWriter, err error) {
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/aftaab/trelay/internal/api/response"
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/report"
+)
+
+type ReportHandler struct {
+	service *report.Service
+}
+
+func NewReportHandler(service *report.Service) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+func (h *ReportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, created)
+}
+
+func (h *ReportHandler) List(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.service.List(r.Context())
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	if reports == nil {
+		reports = []*domain.Report{}
+	}
+	response.JSON(w, http.StatusOK, reports)
+}
+
+func (h *ReportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid report ID")
+		return
+	}
+
+	rep, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, rep)
+}
+
+// Update changes a report's schedule, contents or delivery, or pauses it.
+func (h *ReportHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid report ID")
+		return
+	}
+
+	var req domain.UpdateReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	rep, err := h.service.Update(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, rep)
+}
+
+func (h *ReportHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid report ID")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// Run handles POST /api/v1/reports/{id}/run, delivering the report for its
+// last whole period now. With dry_run=true the rendered document is
+// returned instead of delivered.
+func (h *ReportHandler) Run(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid report ID")
+		return
+	}
+
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+		rep, doc, err := h.service.Preview(r.Context(), id)
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", rep.Format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=report-%d.%s", rep.ID, rep.Format.Extension()))
+		w.WriteHeader(http.StatusOK)
+		w.Write(doc)
+		return
+	}
+
+	run, err := h.service.Run(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, run)
+}
+
+func (h *ReportHandler) handleError(w http.ResponseWriter, err error) {
+	switch err {
+	case domain.ErrReportNotFound:
+		response.NotFound(w, "report not found")
+	case domain.ErrLinkNotFound:
+		response.NotFound(w, "link not found")
+	default:
+		if ve, ok := err.(domain.ValidationError); ok {
+			response.ValidationError(w, ve.Field, ve.Message)
+			return
+		}
+		response.InternalError(w)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
@@ -40,9 +42,23 @@ func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filter := domain.StatsFilter{}
-	if period := r.URL.Query().Get("period"); period != "" {
-		filter.Period = domain.StatsPeriod(period)
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	var compare domain.StatsCompare
+	if compareStr := r.URL.Query().Get("compare"); compareStr != "" {
+		compare = domain.StatsCompare(compareStr)
+		if !compare.IsValid() {
+			response.ValidationError(w, "compare", "compare must be one of: previous, year")
+			return
+		}
+		if filter.StartDate == nil {
+			response.ValidationError(w, "compare", "compare needs a period or from date")
+			return
+		}
 	}
 
 	stats, err := h.analyticsService.GetStats(r.Context(), linkData.ID, filter)
@@ -51,9 +67,23 @@ func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if compare != "" {
+		if err := h.analyticsService.Compare(r.Context(), linkData.ID, stats, filter, compare); err != nil {
+			h.handleError(w, err)
+			return
+		}
+	}
+
 	// Redirect counter on `links` can exceed analytics rows (e.g. analytics off, or tools that only bump count).
-	if linkData.ClickCount > stats.TotalClicks {
-		stats.TotalClicks = linkData.ClickCount
+	// It counts bot hits too, so those are taken off when stats leave bots out.
+	if filter.Channel == "" && !filter.HasRange() && filter.Bots != domain.BotsOnly {
+		counted := linkData.ClickCount
+		if filter.Bots == domain.BotsExclude {
+			counted -= stats.BotClicks
+		}
+		if counted > stats.TotalClicks {
+			stats.TotalClicks = counted
+		}
 	}
 
 	exportFormat := r.URL.Query().Get("export")
@@ -67,6 +97,188 @@ func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetWorkspaceStats returns stats across all links, optionally scoped to a
+// folder or tag.
+func (h *StatsHandler) GetWorkspaceStats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	if err := parseLinkScope(r, &filter); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 {
+			days = d
+		}
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	stats, err := h.analyticsService.GetWorkspaceStats(r.Context(), days, limit, filter)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, stats)
+}
+
+// ExportClicks streams one page of a link's raw clicks as CSV or NDJSON.
+func (h *StatsHandler) ExportClicks(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		response.BadRequest(w, "slug is required")
+		return
+	}
+
+	linkData, err := h.getLinkBySlugForStats(r, slug)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeClicks(w, r, linkData.ID, slug+"-clicks", filter, func(int64) string { return slug })
+}
+
+// ExportWorkspaceClicks streams one page of raw clicks across all links,
+// optionally scoped to a folder or tag.
+func (h *StatsHandler) ExportWorkspaceClicks(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if err := parseLinkScope(r, &filter); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	slugs := make(map[int64]string)
+	slugOf := func(linkID int64) string {
+		slug, ok := slugs[linkID]
+		if !ok {
+			if l, err := h.linkService.GetByID(r.Context(), linkID); err == nil {
+				slug = l.Slug
+			}
+			slugs[linkID] = slug
+		}
+		return slug
+	}
+
+	h.writeClicks(w, r, 0, "trelay-clicks", filter, slugOf)
+}
+
+// clickExportColumns are the CSV header and NDJSON keys of exported clicks.
+var clickExportColumns = []string{
+	"id", "timestamp", "slug", "referrer", "referrer_host", "channel",
+	"device_type", "browser", "os", "country", "region", "city", "bot",
+	"source",
+}
+
+// writeClicks writes a page of clicks in the requested format. The cursor
+// for the next page is sent in the X-Next-Cursor header and as a Link
+// header; both are absent on the last page.
+func (h *StatsHandler) writeClicks(w http.ResponseWriter, r *http.Request, linkID int64, filename string, filter domain.StatsFilter, slugOf func(int64) string) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		response.ValidationError(w, "format", "format must be one of: csv, ndjson")
+		return
+	}
+
+	var cursor int64
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		c, err := strconv.ParseInt(cursorStr, 10, 64)
+		if err != nil || c < 0 {
+			response.ValidationError(w, "cursor", "cursor must be a value from X-Next-Cursor")
+			return
+		}
+		cursor = c
+	}
+
+	limit := 1000
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	clicks, next, err := h.analyticsService.ExportClicks(r.Context(), linkID, filter, cursor, limit)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	if next != 0 {
+		nextCursor := strconv.FormatInt(next, 10)
+		query.Set("cursor", nextCursor)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+	}
+
+	loc := filter.Loc()
+	row := func(c *domain.Click) []string {
+		return []string{
+			strconv.FormatInt(c.ID, 10), c.Timestamp.In(loc).Format(time.RFC3339), slugOf(c.LinkID),
+			c.Referrer, c.ReferrerHost, c.Channel, c.DeviceType, c.Browser, c.OS, c.Country, c.Region, c.City,
+			c.Bot, c.Source,
+		}
+	}
+
+	if format == "ndjson" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.ndjson", filename))
+
+		encoder := json.NewEncoder(w)
+		for _, c := range clicks {
+			values := row(c)
+			obj := make(map[string]interface{}, len(values))
+			for i, column := range clickExportColumns {
+				obj[column] = values[i]
+			}
+			obj["id"] = c.ID
+			if err := encoder.Encode(obj); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	writer.Write(clickExportColumns)
+	for _, c := range clicks {
+		if err := writer.Write(row(c)); err != nil {
+			return
+		}
+	}
+}
+
 func (h *StatsHandler) exportCSV(w http.ResponseWriter, slug string, stats *domain.ClickStats) {
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-stats.csv", slug))
@@ -76,12 +288,22 @@ func (h *StatsHandler) exportCSV(w http.ResponseWriter, slug string, stats *doma
 
 	writer.Write([]string{"metric", "value"})
 	writer.Write([]string{"total_clicks", strconv.FormatInt(stats.TotalClicks, 10)})
+	writer.Write([]string{"unique_visitors", strconv.FormatInt(stats.UniqueVisitors, 10)})
+	writer.Write([]string{"conversions", strconv.FormatInt(stats.Conversions, 10)})
+	writer.Write([]string{"conversion_rate_pct", strconv.FormatFloat(stats.ConversionRate, 'f', 1, 64)})
+	writer.Write([]string{"bot_clicks", strconv.FormatInt(stats.BotClicks, 10)})
+	if c := stats.Comparison; c != nil {
+		writer.Write([]string{"previous_total_clicks", strconv.FormatInt(c.TotalClicks.Previous, 10)})
+		writer.Write([]string{"total_clicks_change_pct", formatChange(c.TotalClicks.Change)})
+		writer.Write([]string{"previous_unique_visitors", strconv.FormatInt(c.UniqueVisitors.Previous, 10)})
+		writer.Write([]string{"unique_visitors_change_pct", formatChange(c.UniqueVisitors.Change)})
+	}
 	writer.Write([]string{})
 
 	if len(stats.ClicksByDay) > 0 {
-		writer.Write([]string{"date", "clicks"})
+		writer.Write([]string{"date", "clicks", "visitors"})
 		for _, d := range stats.ClicksByDay {
-			writer.Write([]string{d.Date, strconv.FormatInt(d.Clicks, 10)})
+			writer.Write([]string{d.Date, strconv.FormatInt(d.Clicks, 10), strconv.FormatInt(d.Visitors, 10)})
 		}
 		writer.Write([]string{})
 	}
@@ -91,9 +313,42 @@ func (h *StatsHandler) exportCSV(w http.ResponseWriter, slug string, stats *doma
 		for _, r := range stats.TopReferrers {
 			writer.Write([]string{r.Referrer, strconv.FormatInt(r.Clicks, 10)})
 		}
+		writer.Write([]string{})
+	}
+
+	if len(stats.TopCountries) > 0 {
+		writer.Write([]string{"country", "clicks"})
+		for _, c := range stats.TopCountries {
+			writer.Write([]string{c.Country, strconv.FormatInt(c.Clicks, 10)})
+		}
+		writer.Write([]string{})
+	}
+
+	if len(stats.Sources) > 0 {
+		writer.Write([]string{"source", "clicks"})
+		for _, s := range stats.Sources {
+			writer.Write([]string{s.Source, strconv.FormatInt(s.Clicks, 10)})
+		}
+		writer.Write([]string{})
+	}
+
+	if len(stats.Goals) > 0 {
+		writer.Write([]string{"goal", "conversions", "rate_pct"})
+		for _, g := range stats.Goals {
+			writer.Write([]string{g.Goal, strconv.FormatInt(g.Conversions, 10), strconv.FormatFloat(g.Rate, 'f', 1, 64)})
+		}
 	}
 }
 
+// formatChange renders a percentage change for CSV, empty when there was
+// nothing to compare with.
+func formatChange(change *float64) string {
+	if change == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*change, 'f', 1, 64)
+}
+
 func (h *StatsHandler) exportJSON(w http.ResponseWriter, slug string, stats *domain.ClickStats) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-stats.json", slug))
@@ -101,6 +356,41 @@ func (h *StatsHandler) exportJSON(w http.ResponseWriter, slug string, stats *dom
 	json.NewEncoder(w).Encode(stats)
 }
 
+func (h *StatsHandler) GetHourlyStats(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		response.BadRequest(w, "slug is required")
+		return
+	}
+
+	linkData, err := h.getLinkBySlugForStats(r, slug)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	hours := 24
+	if hoursStr := r.URL.Query().Get("hours"); hoursStr != "" {
+		if n, err := strconv.Atoi(hoursStr); err == nil && n > 0 {
+			hours = n
+		}
+	}
+
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	stats, err := h.analyticsService.GetClicksByHour(r.Context(), linkData.ID, hours, filter)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, stats)
+}
+
 func (h *StatsHandler) GetDailyStats(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 	if slug == "" {
@@ -121,7 +411,13 @@ func (h *StatsHandler) GetDailyStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := h.analyticsService.GetClicksByDay(r.Context(), linkData.ID, days)
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	stats, err := h.analyticsService.GetClicksByDay(r.Context(), linkData.ID, days, filter)
 	if err != nil {
 		response.InternalError(w)
 		return
@@ -150,7 +446,13 @@ func (h *StatsHandler) GetMonthlyStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := h.analyticsService.GetClicksByMonth(r.Context(), linkData.ID, months)
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	stats, err := h.analyticsService.GetClicksByMonth(r.Context(), linkData.ID, months, filter)
 	if err != nil {
 		response.InternalError(w)
 		return
@@ -179,7 +481,13 @@ func (h *StatsHandler) GetReferrers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := h.analyticsService.GetTopReferrers(r.Context(), linkData.ID, limit)
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	stats, err := h.analyticsService.GetTopReferrers(r.Context(), linkData.ID, limit, filter)
 	if err != nil {
 		response.InternalError(w)
 		return
@@ -188,6 +496,168 @@ func (h *StatsHandler) GetReferrers(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, stats)
 }
 
+func (h *StatsHandler) GetGeoStats(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		response.BadRequest(w, "slug is required")
+		return
+	}
+
+	linkData, err := h.getLinkBySlugForStats(r, slug)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	stats, err := h.analyticsService.GetGeoStats(r.Context(), linkData.ID, limit, filter)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, stats)
+}
+
+func (h *StatsHandler) GetChannels(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		response.BadRequest(w, "slug is required")
+		return
+	}
+
+	linkData, err := h.getLinkBySlugForStats(r, slug)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	hosts := 5
+	if hostsStr := r.URL.Query().Get("hosts"); hostsStr != "" {
+		if n, err := strconv.Atoi(hostsStr); err == nil && n > 0 {
+			hosts = n
+		}
+	}
+
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	stats, err := h.analyticsService.GetChannels(r.Context(), linkData.ID, hosts, filter)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, stats)
+}
+
+// parseStatsFilter reads the query parameters shared by all stats endpoints.
+// An explicit from/to range takes precedence over period.
+func parseStatsFilter(r *http.Request) (domain.StatsFilter, error) {
+	filter := domain.StatsFilter{}
+	query := r.URL.Query()
+
+	if tz := query.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return filter, domain.NewValidationError("tz", "tz must be an IANA time zone such as Europe/Berlin")
+		}
+		filter.Location = loc
+	}
+
+	if period := query.Get("period"); period != "" {
+		filter.Period = domain.StatsPeriod(period)
+		if !filter.Period.IsValid() {
+			return filter, domain.NewValidationError("period", "period must be one of: day, week, month, year, all")
+		}
+		filter.StartDate = filter.Period.Since(time.Now().UTC())
+	}
+
+	if from := query.Get("from"); from != "" {
+		start, err := parseStatsTime(from, filter.Loc(), false)
+		if err != nil {
+			return filter, domain.NewValidationError("from", "from must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+		}
+		filter.StartDate = &start
+	}
+
+	if to := query.Get("to"); to != "" {
+		end, err := parseStatsTime(to, filter.Loc(), true)
+		if err != nil {
+			return filter, domain.NewValidationError("to", "to must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+		}
+		filter.EndDate = &end
+	}
+
+	if filter.StartDate != nil && filter.EndDate != nil && filter.EndDate.Before(*filter.StartDate) {
+		return filter, domain.NewValidationError("to", "to must not be before from")
+	}
+
+	if channel := query.Get("channel"); channel != "" {
+		if !domain.IsReferrerChannel(channel) {
+			return filter, domain.NewValidationError("channel", "channel must be one of: "+strings.Join(domain.ReferrerChannels, ", "))
+		}
+		filter.Channel = channel
+	}
+
+	if bots := domain.BotTraffic(query.Get("bots")); bots != domain.BotsExclude {
+		if !bots.IsValid() {
+			return filter, domain.NewValidationError("bots", "bots must be one of: include, only")
+		}
+		filter.Bots = bots
+	}
+
+	return filter, nil
+}
+
+// parseStatsTime parses an RFC 3339 timestamp, or a date at the start of
+// that day in loc. With endOfDay a date means the end of that day, so a
+// range ending on it includes the whole day. The result is in UTC, which
+// is how click timestamps are stored.
+func parseStatsTime(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return day.UTC(), nil
+}
+
+// parseLinkScope reads the folder_id and tag parameters that narrow
+// workspace-wide stats.
+func parseLinkScope(r *http.Request, filter *domain.StatsFilter) error {
+	if folderIDStr := r.URL.Query().Get("folder_id"); folderIDStr != "" {
+		folderID, err := strconv.ParseInt(folderIDStr, 10, 64)
+		if err != nil {
+			return domain.NewValidationError("folder_id", "folder_id must be an integer")
+		}
+		filter.FolderID = &folderID
+	}
+	filter.Tag = r.URL.Query().Get("tag")
+	return nil
+}
+
 func (h *StatsHandler) getLinkBySlugForStats(r *http.Request, slug string) (*domain.Link, error) {
 	linkData, err := h.linkService.Get(r.Context(), slug, "")
 	if err == domain.ErrPasswordRequired {
@@ -205,6 +675,10 @@ func (h *StatsHandler) handleError(w http.ResponseWriter, err error) {
 	case domain.ErrPasswordRequired:
 		response.Error(w, http.StatusUnauthorized, "password_required", "this link requires a password")
 	default:
+		if ve, ok := err.(domain.ValidationError); ok {
+			response.ValidationError(w, ve.Field, ve.Message)
+			return
+		}
 		response.InternalError(w)
 	}
 }
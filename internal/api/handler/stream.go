@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aftaab/trelay/internal/api/response"
+	"github.com/aftaab/trelay/internal/core/analytics"
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/link"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment so proxies
+// and clients do not time it out.
+const streamKeepAlive = 15 * time.Second
+
+type StreamHandler struct {
+	linkService      *link.Service
+	analyticsService *analytics.Service
+}
+
+func NewStreamHandler(linkService *link.Service, analyticsService *analytics.Service) *StreamHandler {
+	return &StreamHandler{
+		linkService:      linkService,
+		analyticsService: analyticsService,
+	}
+}
+
+// Clicks streams recorded clicks as Server-Sent Events, optionally limited
+// to one slug, folder or tag.
+func (h *StreamHandler) Clicks(w http.ResponseWriter, r *http.Request) {
+	filter := domain.ClickStreamFilter{
+		Slug: r.URL.Query().Get("slug"),
+		Tag:  r.URL.Query().Get("tag"),
+	}
+
+	if folderIDStr := r.URL.Query().Get("folder_id"); folderIDStr != "" {
+		folderID, err := strconv.ParseInt(folderIDStr, 10, 64)
+		if err != nil {
+			response.ValidationError(w, "folder_id", "folder_id must be an integer")
+			return
+		}
+		filter.FolderID = &folderID
+	}
+
+	if filter.Slug != "" {
+		_, err := h.linkService.Get(r.Context(), filter.Slug, "")
+		switch err {
+		case nil, domain.ErrPasswordRequired:
+		case domain.ErrLinkNotFound, domain.ErrLinkDeleted:
+			response.NotFound(w, "link not found")
+			return
+		case domain.ErrLinkExpired:
+			response.Error(w, http.StatusGone, "link_expired", "this link has expired")
+			return
+		default:
+			response.InternalError(w)
+			return
+		}
+	}
+
+	// Streams outlive the server's write timeout.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		response.InternalError(w)
+		return
+	}
+
+	clicks, cancel := h.analyticsService.SubscribeClicks(filter)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case click, ok := <-clicks:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(click)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: click\ndata: %s\n\n", data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/aftaab/trelay/internal/api/response"
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/webhook"
+)
+
+type WebhookHandler struct {
+	service *webhook.Service
+}
+
+func NewWebhookHandler(service *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, created)
+}
+
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.List(r.Context())
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	if webhooks == nil {
+		webhooks = []*domain.Webhook{}
+	}
+	response.JSON(w, http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid webhook ID")
+		return
+	}
+
+	hook, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, hook)
+}
+
+// Update changes a webhook's URL, events or secret, or pauses it.
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid webhook ID")
+		return
+	}
+
+	var req domain.UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	hook, err := h.service.Update(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, hook)
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid webhook ID")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// Deliveries handles GET /api/v1/webhooks/{id}/deliveries, the webhook's
+// delivery log, optionally filtered by status.
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid webhook ID")
+		return
+	}
+
+	filter := domain.WebhookDeliveryFilter{
+		Status: domain.DeliveryStatus(r.URL.Query().Get("status")),
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 {
+			response.BadRequest(w, "invalid limit")
+			return
+		}
+	}
+
+	deliveries, err := h.service.Deliveries(r.Context(), id, filter)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+	response.JSON(w, http.StatusOK, deliveries)
+}
+
+// Test handles POST /api/v1/webhooks/{id}/test, sending a webhook.test
+// event now and returning the logged delivery. A failed delivery is
+// reported in the response rather than as an error.
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.BadRequest(w, "invalid webhook ID")
+		return
+	}
+
+	delivery, err := h.service.Test(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, delivery)
+}
+
+func (h *WebhookHandler) handleError(w http.ResponseWriter, err error) {
+	switch err {
+	case domain.ErrWebhookNotFound:
+		response.NotFound(w, "webhook not found")
+	default:
+		if ve, ok := err.(domain.ValidationError); ok {
+			response.ValidationError(w, ve.Field, ve.Message)
+			return
+		}
+		response.InternalError(w)
+	}
+}
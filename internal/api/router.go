@@ -18,6 +18,9 @@ import (
 	"github.com/aftaab/trelay/internal/core/folder"
 	"github.com/aftaab/trelay/internal/core/link"
 	"github.com/aftaab/trelay/internal/core/preview"
+	"github.com/aftaab/trelay/internal/core/qr"
+	"github.com/aftaab/trelay/internal/core/report"
+	"github.com/aftaab/trelay/internal/core/webhook"
 )
 
 type RouterConfig struct {
@@ -27,6 +30,11 @@ type RouterConfig struct {
 	RateLimitPerMin int
 	Logger          zerolog.Logger
 	StaticDir       string
+
+	// BaseURL is the public URL short links are served under, which QR
+	// codes encode along with custom domains.
+	BaseURL string
+	QR      *qr.Generator
 }
 
 func NewRouter(
@@ -34,6 +42,8 @@ func NewRouter(
 	linkService *link.Service,
 	analyticsService *analytics.Service,
 	folderService *folder.Service,
+	reportService *report.Service,
+	webhookService *webhook.Service,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
@@ -50,13 +60,13 @@ func NewRouter(
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Next-Cursor"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
 	previewService := preview.NewService()
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(analyticsService)
 	authHandler := handler.NewAuthHandler(jwtManager, cfg.APIKeyHash)
 	linkHandler := handler.NewLinkHandler(linkService)
 	statsHandler := handler.NewStatsHandler(linkService, analyticsService)
@@ -64,6 +74,11 @@ func NewRouter(
 	folderHandler := handler.NewFolderHandler(folderService)
 	importHandler := handler.NewImportHandler(linkService)
 	redirectHandler := handler.NewRedirectHandler(linkService, analyticsService)
+	streamHandler := handler.NewStreamHandler(linkService, analyticsService)
+	conversionHandler := handler.NewConversionHandler(analyticsService)
+	qrHandler := handler.NewQRHandler(linkService, cfg.QR, cfg.BaseURL)
+	reportHandler := handler.NewReportHandler(reportService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
 
 	r.Get("/healthz", healthHandler.Health)
 	r.Get("/health", healthHandler.Health)
@@ -73,6 +88,11 @@ func NewRouter(
 		r.Post("/auth/login", authHandler.Login)
 		r.Post("/auth/refresh", authHandler.Refresh)
 
+		// Conversions are reported by destination sites, which only hold
+		// the click token.
+		r.Post("/conversions", conversionHandler.Record)
+		r.Get("/conversions/pixel.gif", conversionHandler.Pixel)
+
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.Auth(cfg.APIKeyHash, jwtManager))
 
@@ -80,24 +100,50 @@ func NewRouter(
 			r.Get("/links", linkHandler.List)
 			r.Patch("/links/bulk", linkHandler.BulkUpdate)
 			r.Post("/links/bulk/restore", linkHandler.BulkRestore)
+			r.Post("/links/check", linkHandler.Check)
 			r.Delete("/links", linkHandler.BulkDelete)
 			r.Get("/links/{slug}", linkHandler.Get)
+			r.Get("/links/{slug}/qr", qrHandler.Get)
 			r.Patch("/links/{slug}", linkHandler.Update)
 			r.Delete("/links/{slug}", linkHandler.Delete)
 			r.Post("/links/{slug}/restore", linkHandler.Restore)
 
 			r.Get("/preview", previewHandler.Fetch)
 
+			r.Get("/stats", statsHandler.GetWorkspaceStats)
 			r.Get("/stats/{slug}", statsHandler.GetStats)
+			r.Get("/stats/{slug}/hourly", statsHandler.GetHourlyStats)
 			r.Get("/stats/{slug}/daily", statsHandler.GetDailyStats)
 			r.Get("/stats/{slug}/monthly", statsHandler.GetMonthlyStats)
 			r.Get("/stats/{slug}/referrers", statsHandler.GetReferrers)
+			r.Get("/stats/{slug}/geo", statsHandler.GetGeoStats)
+			r.Get("/stats/{slug}/channels", statsHandler.GetChannels)
+			r.Get("/stats/{slug}/clicks", statsHandler.ExportClicks)
+			r.Get("/clicks", statsHandler.ExportWorkspaceClicks)
+
+			r.Get("/stream/clicks", streamHandler.Clicks)
 
 			r.Post("/folders", folderHandler.Create)
 			r.Get("/folders", folderHandler.List)
 			r.Get("/folders/{id}", folderHandler.Get)
+			r.Patch("/folders/{id}", folderHandler.Update)
 			r.Delete("/folders/{id}", folderHandler.Delete)
 
+			r.Post("/reports", reportHandler.Create)
+			r.Get("/reports", reportHandler.List)
+			r.Get("/reports/{id}", reportHandler.Get)
+			r.Patch("/reports/{id}", reportHandler.Update)
+			r.Delete("/reports/{id}", reportHandler.Delete)
+			r.Post("/reports/{id}/run", reportHandler.Run)
+
+			r.Post("/webhooks", webhookHandler.Create)
+			r.Get("/webhooks", webhookHandler.List)
+			r.Get("/webhooks/{id}", webhookHandler.Get)
+			r.Patch("/webhooks/{id}", webhookHandler.Update)
+			r.Delete("/webhooks/{id}", webhookHandler.Delete)
+			r.Get("/webhooks/{id}/deliveries", webhookHandler.Deliveries)
+			r.Post("/webhooks/{id}/test", webhookHandler.Test)
+
 			r.Post("/import", importHandler.Import)
 			r.Post("/import/json", importHandler.ImportJSON)
 			r.Get("/export", importHandler.Export)
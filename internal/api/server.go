@@ -45,6 +45,12 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// OnShutdown registers f to run when Shutdown begins, e.g. to end
+// long-lived streams that would otherwise hold the shutdown open.
+func (s *Server) OnShutdown(f func()) {
+	s.server.RegisterOnShutdown(f)
+}
+
 // Shutdown gracefully stops the server.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info().Msg("shutting down HTTP server")
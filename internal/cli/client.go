@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -47,7 +50,17 @@ type APIMeta struct {
 	Offset int   `json:"offset,omitempty"`
 }
 
+// checkTimeout bounds each on-demand link check request.
+const checkTimeout = 2 * time.Minute
+
+// checkBatch is the most links the server checks per request.
+const checkBatch = 20
+
 func (c *Client) do(method, path string, body interface{}, result interface{}) error {
+	return c.doWith(c.httpClient, method, path, body, result)
+}
+
+func (c *Client) doWith(httpClient *http.Client, method, path string, body interface{}, result interface{}) error {
 	var reqBody io.Reader
 	if body != nil {
 		data, err := json.Marshal(body)
@@ -66,7 +79,7 @@ func (c *Client) do(method, path string, body interface{}, result interface{}) e
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-API-Key", c.apiKey)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -99,16 +112,44 @@ func (c *Client) do(method, path string, body interface{}, result interface{}) e
 }
 
 type Link struct {
-	ID          int64    `json:"id"`
-	Slug        string   `json:"slug"`
-	OriginalURL string   `json:"original_url"`
-	Domain      string   `json:"domain,omitempty"`
-	HasPassword bool     `json:"has_password"`
-	ExpiresAt   *string  `json:"expires_at,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
-	ClickCount  int64    `json:"click_count"`
-	CreatedAt   string   `json:"created_at"`
-	UpdatedAt   string   `json:"updated_at"`
+	ID          int64        `json:"id"`
+	Slug        string       `json:"slug"`
+	OriginalURL string       `json:"original_url"`
+	Domain      string       `json:"domain,omitempty"`
+	HasPassword bool         `json:"has_password"`
+	ExpiresAt   *string      `json:"expires_at,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Preview     *LinkPreview `json:"preview,omitempty"`
+	Health      *LinkHealth  `json:"health,omitempty"`
+	ClickCount  int64        `json:"click_count"`
+	CreatedAt   string       `json:"created_at"`
+	UpdatedAt   string       `json:"updated_at"`
+
+	Privacy AnalyticsPrivacy `json:"privacy"`
+}
+
+type AnalyticsPrivacy struct {
+	DisableTracking bool `json:"disable_tracking,omitempty"`
+	DropReferrer    bool `json:"drop_referrer,omitempty"`
+	DropUserAgent   bool `json:"drop_user_agent,omitempty"`
+	HonorDNT        bool `json:"honor_dnt,omitempty"`
+}
+
+type LinkPreview struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+	FaviconURL  string `json:"favicon_url,omitempty"`
+	FetchedAt   string `json:"fetched_at"`
+}
+
+type LinkHealth struct {
+	CheckedAt     string `json:"checked_at,omitempty"`
+	StatusCode    int    `json:"status_code,omitempty"`
+	RedirectURL   string `json:"redirect_url,omitempty"`
+	FailureStreak int    `json:"failure_streak"`
+	Error         string `json:"error,omitempty"`
 }
 
 type CreateLinkRequest struct {
@@ -119,6 +160,8 @@ type CreateLinkRequest struct {
 	TTLHours  int      `json:"ttl_hours,omitempty"`
 	Tags      []string `json:"tags,omitempty"`
 	IsOneTime bool     `json:"is_one_time,omitempty"`
+	// Privacy defaults to the folder's settings when nil.
+	Privacy *AnalyticsPrivacy `json:"privacy,omitempty"`
 }
 
 func (c *Client) CreateLink(req CreateLinkRequest) (*Link, error) {
@@ -141,6 +184,7 @@ type ListLinksOptions struct {
 	Search   string
 	Tags     []string
 	FolderID *int64
+	Broken   bool
 	Limit    int
 	Offset   int
 }
@@ -158,6 +202,9 @@ func (c *Client) ListLinks(opts ListLinksOptions) ([]Link, error) {
 	if opts.FolderID != nil {
 		params.Set("folder_id", fmt.Sprintf("%d", *opts.FolderID))
 	}
+	if opts.Broken {
+		params.Set("broken", "true")
+	}
 	if opts.Limit > 0 {
 		params.Set("limit", fmt.Sprintf("%d", opts.Limit))
 	}
@@ -206,30 +253,390 @@ func (c *Client) BulkDeleteLinks(slugs []string, permanent bool) (*BulkDeleteRes
 	return &result, nil
 }
 
+type CheckLinksRequest struct {
+	Slugs    []string `json:"slugs,omitempty"`
+	FolderID *int64   `json:"folder_id,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+	Offset   int      `json:"offset,omitempty"`
+}
+
+type LinkCheckResult struct {
+	Slug        string `json:"slug"`
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+	Broken      bool   `json:"broken"`
+	Error       string `json:"error,omitempty"`
+}
+
+// CheckLinks checks the selected links a batch at a time, as the server
+// only probes a few destinations per request.
+func (c *Client) CheckLinks(req CheckLinksRequest) ([]LinkCheckResult, error) {
+	httpClient := &http.Client{Timeout: checkTimeout}
+	var results []LinkCheckResult
+
+	if len(req.Slugs) > 0 {
+		slugs := req.Slugs
+		for len(slugs) > 0 {
+			n := min(len(slugs), checkBatch)
+			var page []LinkCheckResult
+			batch := CheckLinksRequest{Slugs: slugs[:n]}
+			if err := c.doWith(httpClient, "POST", "/api/v1/links/check", batch, &page); err != nil {
+				return nil, err
+			}
+			results = append(results, page...)
+			slugs = slugs[n:]
+		}
+		return results, nil
+	}
+
+	req.Limit = checkBatch
+	for {
+		var page []LinkCheckResult
+		if err := c.doWith(httpClient, "POST", "/api/v1/links/check", req, &page); err != nil {
+			return nil, err
+		}
+		results = append(results, page...)
+		if len(page) < checkBatch {
+			return results, nil
+		}
+		req.Offset += len(page)
+	}
+}
+
 type ClickStats struct {
-	TotalClicks  int64           `json:"total_clicks"`
-	ClicksByDay  []DayStats      `json:"clicks_by_day,omitempty"`
-	TopReferrers []ReferrerStats `json:"top_referrers,omitempty"`
+	TotalClicks    int64           `json:"total_clicks"`
+	UniqueVisitors int64           `json:"unique_visitors"`
+	ClicksByDay    []DayStats      `json:"clicks_by_day,omitempty"`
+	ClicksByHour   []HourStats     `json:"clicks_by_hour,omitempty"`
+	TopReferrers   []ReferrerStats `json:"top_referrers,omitempty"`
+	TopCountries   []CountryStats  `json:"top_countries,omitempty"`
+	Channels       []ChannelStats  `json:"channels,omitempty"`
+	Sources        []SourceStats   `json:"sources,omitempty"`
+	Browsers       []BrowserStats  `json:"browsers,omitempty"`
+	OSStats        []OSStats       `json:"operating_systems,omitempty"`
+	DeviceStats    []DeviceStats   `json:"device_stats,omitempty"`
+
+	Conversions    int64       `json:"conversions"`
+	ConversionRate float64     `json:"conversion_rate"`
+	Goals          []GoalStats `json:"goals,omitempty"`
+
+	BotClicks int64      `json:"bot_clicks"`
+	Bots      []BotStats `json:"bots,omitempty"`
+
+	Comparison *StatsComparison `json:"comparison,omitempty"`
+}
+
+type SourceStats struct {
+	Source string `json:"source"`
+	Clicks int64  `json:"clicks"`
 }
 
-type DayStats struct {
-	Date   string `json:"date"`
+type BotStats struct {
+	Class  string `json:"class"`
 	Clicks int64  `json:"clicks"`
 }
 
+type GoalStats struct {
+	Goal        string  `json:"goal"`
+	Conversions int64   `json:"conversions"`
+	Rate        float64 `json:"rate"`
+}
+
+type WorkspaceStats struct {
+	TotalClicks    int64            `json:"total_clicks"`
+	UniqueVisitors int64            `json:"unique_visitors"`
+	ActiveLinks    int64            `json:"active_links"`
+	ClicksByDay    []DayStats       `json:"clicks_by_day,omitempty"`
+	ClicksByHour   []HourStats      `json:"clicks_by_hour,omitempty"`
+	TopLinks       []LinkClickStats `json:"top_links,omitempty"`
+	TopReferrers   []ReferrerStats  `json:"top_referrers,omitempty"`
+	Channels       []ChannelStats   `json:"channels,omitempty"`
+	TopCountries   []CountryStats   `json:"top_countries,omitempty"`
+	DeviceStats    []DeviceStats    `json:"device_stats,omitempty"`
+}
+
+type LinkClickStats struct {
+	Slug     string `json:"slug"`
+	Clicks   int64  `json:"clicks"`
+	Visitors int64  `json:"visitors"`
+}
+
+func (c *Client) GetWorkspaceStats(opts StatsOptions) (*WorkspaceStats, error) {
+	var stats WorkspaceStats
+	if err := c.do("GET", "/api/v1/stats"+opts.query(), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+type StatsComparison struct {
+	Mode           string        `json:"mode"`
+	StartDate      time.Time     `json:"start_date"`
+	EndDate        time.Time     `json:"end_date"`
+	Previous       *ClickStats   `json:"previous"`
+	TotalClicks    MetricDelta   `json:"total_clicks"`
+	UniqueVisitors MetricDelta   `json:"unique_visitors"`
+	TopReferrers   []MetricDelta `json:"top_referrers"`
+	DeviceStats    []MetricDelta `json:"device_stats"`
+}
+
+type MetricDelta struct {
+	Key      string   `json:"key"`
+	Current  int64    `json:"current"`
+	Previous int64    `json:"previous"`
+	Change   *float64 `json:"change"`
+}
+
+type HourStats struct {
+	Hour     string `json:"hour"`
+	Clicks   int64  `json:"clicks"`
+	Visitors int64  `json:"visitors"`
+}
+
+type DayStats struct {
+	Date     string `json:"date"`
+	Clicks   int64  `json:"clicks"`
+	Visitors int64  `json:"visitors"`
+}
+
 type ReferrerStats struct {
 	Referrer string `json:"referrer"`
 	Clicks   int64  `json:"clicks"`
 }
 
-func (c *Client) GetStats(slug string) (*ClickStats, error) {
+type ChannelStats struct {
+	Channel string          `json:"channel"`
+	Clicks  int64           `json:"clicks"`
+	Hosts   []ReferrerStats `json:"hosts,omitempty"`
+}
+
+type CountryStats struct {
+	Country string `json:"country"`
+	Clicks  int64  `json:"clicks"`
+}
+
+type BrowserStats struct {
+	Browser string `json:"browser"`
+	Clicks  int64  `json:"clicks"`
+}
+
+type OSStats struct {
+	OS     string `json:"os"`
+	Clicks int64  `json:"clicks"`
+}
+
+type DeviceStats struct {
+	DeviceType string `json:"device_type"`
+	Clicks     int64  `json:"clicks"`
+}
+
+type StatsOptions struct {
+	Period  string
+	Channel string
+	// From and To take a date (YYYY-MM-DD) or an RFC 3339 timestamp and
+	// override Period. TZ is the IANA time zone days are grouped in.
+	From string
+	To   string
+	TZ   string
+	// Compare is "previous" or "year" and only applies to per-link stats.
+	Compare string
+	// FolderID and Tag only apply to workspace stats.
+	FolderID *int64
+	Tag      string
+	// Bots is "include" or "only" to count bot clicks, which are left out
+	// by default.
+	Bots string
+}
+
+func (o StatsOptions) values() url.Values {
+	params := url.Values{}
+
+	if o.Period != "" {
+		params.Set("period", o.Period)
+	}
+	if o.Channel != "" {
+		params.Set("channel", o.Channel)
+	}
+	if o.Bots != "" {
+		params.Set("bots", o.Bots)
+	}
+	if o.From != "" {
+		params.Set("from", o.From)
+	}
+	if o.To != "" {
+		params.Set("to", o.To)
+	}
+	if o.TZ != "" {
+		params.Set("tz", o.TZ)
+	}
+	if o.Compare != "" {
+		params.Set("compare", o.Compare)
+	}
+	if o.FolderID != nil {
+		params.Set("folder_id", fmt.Sprintf("%d", *o.FolderID))
+	}
+	if o.Tag != "" {
+		params.Set("tag", o.Tag)
+	}
+	return params
+}
+
+func (o StatsOptions) query() string {
+	params := o.values()
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + params.Encode()
+}
+
+func (c *Client) GetStats(slug string, opts StatsOptions) (*ClickStats, error) {
+	path := "/api/v1/stats/" + slug + opts.query()
+
 	var stats ClickStats
-	if err := c.do("GET", "/api/v1/stats/"+slug, nil, &stats); err != nil {
+	if err := c.do("GET", path, nil, &stats); err != nil {
 		return nil, err
 	}
 	return &stats, nil
 }
 
+// ExportClicks writes one page of raw clicks to w in format ("csv" or
+// "ndjson") and returns the cursor of the next page, or "" after the last.
+// With an empty slug clicks of every link are exported.
+func (c *Client) ExportClicks(slug, format string, opts StatsOptions, cursor string, w io.Writer) (string, error) {
+	params := opts.values()
+	params.Set("format", format)
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	path := "/api/v1/clicks"
+	if slug != "" {
+		path = "/api/v1/stats/" + slug + "/clicks"
+	}
+
+	req, err := http.NewRequest("GET", c.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiResp APIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Error != nil {
+			return "", fmt.Errorf("%s: %s", apiResp.Error.Code, apiResp.Error.Message)
+		}
+		return "", fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.Header.Get("X-Next-Cursor"), nil
+}
+
+type LiveClick struct {
+	Slug         string    `json:"slug"`
+	Timestamp    time.Time `json:"timestamp"`
+	Referrer     string    `json:"referrer,omitempty"`
+	ReferrerHost string    `json:"referrer_host,omitempty"`
+	Channel      string    `json:"channel,omitempty"`
+	Country      string    `json:"country,omitempty"`
+	City         string    `json:"city,omitempty"`
+	Browser      string    `json:"browser,omitempty"`
+	OS           string    `json:"os,omitempty"`
+	DeviceType   string    `json:"device_type,omitempty"`
+}
+
+type WatchOptions struct {
+	Slug     string
+	FolderID *int64
+	Tag      string
+}
+
+// WatchClicks follows the server's click stream, calling fn for each click
+// until ctx is cancelled or the server ends the stream.
+func (c *Client) WatchClicks(ctx context.Context, opts WatchOptions, fn func(LiveClick) error) error {
+	params := url.Values{}
+	if opts.Slug != "" {
+		params.Set("slug", opts.Slug)
+	}
+	if opts.FolderID != nil {
+		params.Set("folder_id", fmt.Sprintf("%d", *opts.FolderID))
+	}
+	if opts.Tag != "" {
+		params.Set("tag", opts.Tag)
+	}
+
+	path := "/api/v1/stream/clicks"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	// No client timeout: the stream stays open until one side closes it.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiResp APIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Error != nil {
+			return fmt.Errorf("%s: %s", apiResp.Error.Code, apiResp.Error.Message)
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "click" && data != "" {
+				var click LiveClick
+				if err := json.Unmarshal([]byte(data), &click); err != nil {
+					return fmt.Errorf("failed to parse click: %w", err)
+				}
+				if err := fn(click); err != nil {
+					return err
+				}
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream interrupted: %w", err)
+	}
+	return fmt.Errorf("stream closed by server")
+}
+
 type Folder struct {
 	ID        int64  `json:"id"`
 	Name      string `json:"name"`
@@ -263,6 +670,129 @@ func (c *Client) DeleteFolder(id int64) error {
 	return c.do("DELETE", fmt.Sprintf("/api/v1/folders/%d", id), nil, nil)
 }
 
+type Report struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Scope      string   `json:"scope"`
+	Slug       string   `json:"slug,omitempty"`
+	FolderID   *int64   `json:"folder_id,omitempty"`
+	Tag        string   `json:"tag,omitempty"`
+	Cadence    string   `json:"cadence"`
+	Timezone   string   `json:"timezone"`
+	Metrics    []string `json:"metrics,omitempty"`
+	Format     string   `json:"format"`
+	Delivery   string   `json:"delivery"`
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	Directory  string   `json:"directory,omitempty"`
+	Enabled    bool     `json:"enabled"`
+	NextRunAt  string   `json:"next_run_at"`
+	LastRunAt  *string  `json:"last_run_at,omitempty"`
+	LastError  string   `json:"last_error,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type CreateReportRequest struct {
+	Name       string   `json:"name"`
+	Scope      string   `json:"scope"`
+	Slug       string   `json:"slug,omitempty"`
+	FolderID   *int64   `json:"folder_id,omitempty"`
+	Tag        string   `json:"tag,omitempty"`
+	Cadence    string   `json:"cadence"`
+	Timezone   string   `json:"timezone,omitempty"`
+	Metrics    []string `json:"metrics,omitempty"`
+	Format     string   `json:"format,omitempty"`
+	Delivery   string   `json:"delivery"`
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	Directory  string   `json:"directory,omitempty"`
+}
+
+// UpdateReportRequest changes only the fields that are set.
+type UpdateReportRequest struct {
+	Name       *string  `json:"name,omitempty"`
+	Cadence    *string  `json:"cadence,omitempty"`
+	Timezone   *string  `json:"timezone,omitempty"`
+	Metrics    []string `json:"metrics,omitempty"`
+	Format     *string  `json:"format,omitempty"`
+	Delivery   *string  `json:"delivery,omitempty"`
+	WebhookURL *string  `json:"webhook_url,omitempty"`
+	Directory  *string  `json:"directory,omitempty"`
+	Enabled    *bool    `json:"enabled,omitempty"`
+}
+
+type ReportRun struct {
+	ReportID int64  `json:"report_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	RanAt    string `json:"ran_at"`
+	Location string `json:"location,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (c *Client) CreateReport(req CreateReportRequest) (*Report, error) {
+	var report Report
+	if err := c.do("POST", "/api/v1/reports", req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (c *Client) ListReports() ([]Report, error) {
+	var reports []Report
+	if err := c.do("GET", "/api/v1/reports", nil, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (c *Client) UpdateReport(id int64, req UpdateReportRequest) (*Report, error) {
+	var report Report
+	if err := c.do("PATCH", fmt.Sprintf("/api/v1/reports/%d", id), req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (c *Client) DeleteReport(id int64) error {
+	return c.do("DELETE", fmt.Sprintf("/api/v1/reports/%d", id), nil, nil)
+}
+
+// RunReport delivers a report for its last whole period now.
+func (c *Client) RunReport(id int64) (*ReportRun, error) {
+	var run ReportRun
+	if err := c.do("POST", fmt.Sprintf("/api/v1/reports/%d/run", id), nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// PreviewReport writes the document a report would deliver now to w.
+func (c *Client) PreviewReport(id int64, w io.Writer) error {
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/v1/reports/%d/run?dry_run=true", c.baseURL, id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiResp APIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Error != nil {
+			return fmt.Errorf("%s: %s", apiResp.Error.Code, apiResp.Error.Message)
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	return nil
+}
+
 type ImportResult struct {
 	Total    int           `json:"total"`
 	Imported int           `json:"imported"`
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 )
 
@@ -48,7 +49,7 @@ func PrintStats(stats *ClickStats, format OutputFormat) error {
 	case OutputFormatCSV:
 		return printStatsCSV(stats)
 	default:
-		return printStatsTable(stats)
+		return printStatsTable(stats, true)
 	}
 }
 
@@ -119,6 +120,65 @@ func printLinkDetails(link *Link) error {
 		fmt.Printf("Tags:        %v\n", link.Tags)
 	}
 
+	if p := link.Privacy; p != (AnalyticsPrivacy{}) {
+		var settings []string
+		if p.DisableTracking {
+			settings = append(settings, "no tracking")
+		}
+		if p.DropReferrer {
+			settings = append(settings, "no referrer")
+		}
+		if p.DropUserAgent {
+			settings = append(settings, "no user agent")
+		}
+		if p.HonorDNT {
+			settings = append(settings, "honors DNT")
+		}
+		fmt.Printf("Privacy:     %s\n", strings.Join(settings, ", "))
+	}
+
+	if p := link.Preview; p != nil {
+		fmt.Println()
+		fmt.Println("Preview:")
+		if p.SiteName != "" {
+			fmt.Printf("  Site:        %s\n", p.SiteName)
+		}
+		if p.Title != "" {
+			fmt.Printf("  Title:       %s\n", p.Title)
+		}
+		if p.Description != "" {
+			desc := p.Description
+			if len(desc) > 100 {
+				desc = desc[:97] + "..."
+			}
+			fmt.Printf("  Description: %s\n", desc)
+		}
+		if p.ImageURL != "" {
+			fmt.Printf("  Image:       %s\n", p.ImageURL)
+		}
+		fmt.Printf("  Fetched:     %s\n", p.FetchedAt)
+	}
+
+	if h := link.Health; h != nil {
+		fmt.Println()
+		fmt.Println("Health:")
+		status := "OK"
+		if h.FailureStreak > 0 {
+			status = fmt.Sprintf("Broken (%d failed checks)", h.FailureStreak)
+		}
+		fmt.Printf("  Status:      %s\n", status)
+		if h.StatusCode != 0 {
+			fmt.Printf("  HTTP:        %d\n", h.StatusCode)
+		}
+		if h.RedirectURL != "" {
+			fmt.Printf("  Redirects:   %s\n", h.RedirectURL)
+		}
+		if h.Error != "" {
+			fmt.Printf("  Error:       %s\n", h.Error)
+		}
+		fmt.Printf("  Checked:     %s\n", h.CheckedAt)
+	}
+
 	return nil
 }
 
@@ -155,15 +215,57 @@ func printLinksCSV(links []Link) error {
 	return nil
 }
 
-func printStatsTable(stats *ClickStats) error {
-	fmt.Printf("Total Clicks: %d\n\n", stats.TotalClicks)
+func printStatsTable(stats *ClickStats, totals bool) error {
+	c := stats.Comparison
+	if totals && c != nil {
+		fmt.Printf("Total Clicks: %d (%s)\n", stats.TotalClicks, formatDelta(c.TotalClicks))
+		fmt.Printf("Unique Visitors: %d (%s)\n", stats.UniqueVisitors, formatDelta(c.UniqueVisitors))
+		fmt.Printf("Compared with: %s to %s\n\n", c.StartDate.Local().Format("2006-01-02 15:04"), c.EndDate.Local().Format("2006-01-02 15:04"))
+	} else if totals {
+		fmt.Printf("Total Clicks: %d\n", stats.TotalClicks)
+		fmt.Printf("Unique Visitors: %d\n\n", stats.UniqueVisitors)
+	}
 
-	if len(stats.ClicksByDay) > 0 {
+	if totals && len(stats.Goals) > 0 {
+		fmt.Printf("Conversions: %d (%.1f%% of clicks)\n", stats.Conversions, stats.ConversionRate)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "GOAL\tCONVERSIONS\tRATE")
+		for _, g := range stats.Goals {
+			fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", g.Goal, g.Conversions, g.Rate)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	if totals && stats.BotClicks > 0 {
+		classes := make([]string, 0, len(stats.Bots))
+		for _, b := range stats.Bots {
+			classes = append(classes, fmt.Sprintf("%s %d", b.Class, b.Clicks))
+		}
+		fmt.Printf("Bot Clicks: %d (%s)\n\n", stats.BotClicks, strings.Join(classes, ", "))
+	}
+
+	// Series are zero-filled for charts; the table only lists active
+	// periods, by hour when the server sent hours for a short range.
+	if len(stats.ClicksByHour) > 0 {
+		fmt.Println("Clicks by Hour:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOUR\tCLICKS\tVISITORS")
+		for _, h := range stats.ClicksByHour {
+			if h.Clicks > 0 {
+				fmt.Fprintf(w, "%s\t%d\t%d\n", h.Hour, h.Clicks, h.Visitors)
+			}
+		}
+		w.Flush()
+		fmt.Println()
+	} else if len(stats.ClicksByDay) > 0 {
 		fmt.Println("Clicks by Day:")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "DATE\tCLICKS")
+		fmt.Fprintln(w, "DATE\tCLICKS\tVISITORS")
 		for _, d := range stats.ClicksByDay {
-			fmt.Fprintf(w, "%s\t%d\n", d.Date, d.Clicks)
+			if d.Clicks > 0 {
+				fmt.Fprintf(w, "%s\t%d\t%d\n", d.Date, d.Clicks, d.Visitors)
+			}
 		}
 		w.Flush()
 		fmt.Println()
@@ -172,13 +274,99 @@ func printStatsTable(stats *ClickStats) error {
 	if len(stats.TopReferrers) > 0 {
 		fmt.Println("Top Referrers:")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "REFERRER\tCLICKS")
-		for _, r := range stats.TopReferrers {
+		if c != nil {
+			fmt.Fprintln(w, "REFERRER\tCLICKS\tCHANGE")
+		} else {
+			fmt.Fprintln(w, "REFERRER\tCLICKS")
+		}
+		for i, r := range stats.TopReferrers {
 			ref := r.Referrer
 			if len(ref) > 50 {
 				ref = ref[:47] + "..."
 			}
-			fmt.Fprintf(w, "%s\t%d\n", ref, r.Clicks)
+			if c != nil && i < len(c.TopReferrers) {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", ref, r.Clicks, formatDelta(c.TopReferrers[i]))
+			} else {
+				fmt.Fprintf(w, "%s\t%d\n", ref, r.Clicks)
+			}
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	if len(stats.Channels) > 0 {
+		fmt.Println("Channels:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CHANNEL\tCLICKS\tTOP SOURCES")
+		for _, c := range stats.Channels {
+			hosts := make([]string, 0, len(c.Hosts))
+			for _, h := range c.Hosts {
+				hosts = append(hosts, h.Referrer)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\n", c.Channel, c.Clicks, strings.Join(hosts, ", "))
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	if len(stats.Sources) > 0 {
+		fmt.Println("Sources:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SOURCE\tCLICKS")
+		for _, s := range stats.Sources {
+			fmt.Fprintf(w, "%s\t%d\n", s.Source, s.Clicks)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	if len(stats.TopCountries) > 0 {
+		fmt.Println("Top Countries:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COUNTRY\tCLICKS")
+		for _, c := range stats.TopCountries {
+			fmt.Fprintf(w, "%s\t%d\n", c.Country, c.Clicks)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	if len(stats.Browsers) > 0 {
+		fmt.Println("Browsers:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BROWSER\tCLICKS")
+		for _, b := range stats.Browsers {
+			fmt.Fprintf(w, "%s\t%d\n", b.Browser, b.Clicks)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	if len(stats.OSStats) > 0 {
+		fmt.Println("Operating Systems:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "OS\tCLICKS")
+		for _, o := range stats.OSStats {
+			fmt.Fprintf(w, "%s\t%d\n", o.OS, o.Clicks)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	if c != nil && len(c.DeviceStats) > 0 {
+		fmt.Println("Devices:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DEVICE\tCLICKS\tCHANGE")
+		for _, d := range c.DeviceStats {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", d.Key, d.Current, formatDelta(d))
+		}
+		w.Flush()
+	} else if len(stats.DeviceStats) > 0 {
+		fmt.Println("Devices:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DEVICE\tCLICKS")
+		for _, d := range stats.DeviceStats {
+			fmt.Fprintf(w, "%s\t%d\n", d.DeviceType, d.Clicks)
 		}
 		w.Flush()
 	}
@@ -186,21 +374,212 @@ func printStatsTable(stats *ClickStats) error {
 	return nil
 }
 
+// formatDelta describes the change from the previous window, such as
+// "+20.0% from 100".
+func formatDelta(d MetricDelta) string {
+	if d.Change == nil {
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%% from %d", *d.Change, d.Previous)
+}
+
 func printStatsCSV(stats *ClickStats) error {
 	w := csv.NewWriter(os.Stdout)
 	defer w.Flush()
 
 	w.Write([]string{"total_clicks", strconv.FormatInt(stats.TotalClicks, 10)})
+	w.Write([]string{"unique_visitors", strconv.FormatInt(stats.UniqueVisitors, 10)})
+	w.Write([]string{"conversions", strconv.FormatInt(stats.Conversions, 10)})
+	w.Write([]string{"conversion_rate_pct", strconv.FormatFloat(stats.ConversionRate, 'f', 1, 64)})
+	w.Write([]string{"bot_clicks", strconv.FormatInt(stats.BotClicks, 10)})
+	if c := stats.Comparison; c != nil {
+		w.Write([]string{"previous_total_clicks", strconv.FormatInt(c.TotalClicks.Previous, 10)})
+		w.Write([]string{"previous_unique_visitors", strconv.FormatInt(c.UniqueVisitors.Previous, 10)})
+	}
 	w.Write([]string{})
-	w.Write([]string{"date", "clicks"})
+	w.Write([]string{"date", "clicks", "visitors"})
 
 	for _, d := range stats.ClicksByDay {
-		w.Write([]string{d.Date, strconv.FormatInt(d.Clicks, 10)})
+		w.Write([]string{d.Date, strconv.FormatInt(d.Clicks, 10), strconv.FormatInt(d.Visitors, 10)})
+	}
+
+	return nil
+}
+
+// PrintWorkspaceStats outputs stats aggregated across links.
+func PrintWorkspaceStats(stats *WorkspaceStats, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		return printJSON(stats)
+	case OutputFormatCSV:
+		return printWorkspaceStatsCSV(stats)
+	default:
+		return printWorkspaceStatsTable(stats)
+	}
+}
+
+func printWorkspaceStatsTable(stats *WorkspaceStats) error {
+	fmt.Printf("Total Clicks: %d\n", stats.TotalClicks)
+	fmt.Printf("Unique Visitors: %d\n", stats.UniqueVisitors)
+	fmt.Printf("Links Clicked: %d\n\n", stats.ActiveLinks)
+
+	if len(stats.TopLinks) > 0 {
+		fmt.Println("Top Links:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SLUG\tCLICKS\tVISITORS")
+		for _, l := range stats.TopLinks {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", l.Slug, l.Clicks, l.Visitors)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+
+	// The remaining sections share the per-link layout.
+	return printStatsTable(&ClickStats{
+		ClicksByDay:  stats.ClicksByDay,
+		ClicksByHour: stats.ClicksByHour,
+		TopReferrers: stats.TopReferrers,
+		Channels:     stats.Channels,
+		TopCountries: stats.TopCountries,
+		DeviceStats:  stats.DeviceStats,
+	}, false)
+}
+
+func printWorkspaceStatsCSV(stats *WorkspaceStats) error {
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	w.Write([]string{"total_clicks", strconv.FormatInt(stats.TotalClicks, 10)})
+	w.Write([]string{"unique_visitors", strconv.FormatInt(stats.UniqueVisitors, 10)})
+	w.Write([]string{"active_links", strconv.FormatInt(stats.ActiveLinks, 10)})
+	w.Write([]string{})
+	w.Write([]string{"slug", "clicks", "visitors"})
+
+	for _, l := range stats.TopLinks {
+		w.Write([]string{l.Slug, strconv.FormatInt(l.Clicks, 10), strconv.FormatInt(l.Visitors, 10)})
+	}
+
+	return nil
+}
+
+// PrintCheckResults outputs link check results.
+func PrintCheckResults(results []LinkCheckResult, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		return printJSON(results)
+	case OutputFormatCSV:
+		return printCheckResultsCSV(results)
+	default:
+		return printCheckResultsTable(results)
+	}
+}
+
+func printCheckResultsTable(results []LinkCheckResult) error {
+	if len(results) == 0 {
+		fmt.Println("No links found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLUG\tSTATUS\tHTTP\tURL\tDETAIL")
+	fmt.Fprintln(w, "----\t------\t----\t---\t------")
+
+	broken := 0
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.URL == "":
+			status = "missing"
+		case r.Broken:
+			status = "broken"
+			broken++
+		}
+
+		code := "-"
+		if r.StatusCode != 0 {
+			code = strconv.Itoa(r.StatusCode)
+		}
+
+		url := r.URL
+		if len(url) > 50 {
+			url = url[:47] + "..."
+		}
+
+		detail := r.Error
+		if detail == "" && r.RedirectURL != "" {
+			detail = "-> " + r.RedirectURL
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Slug, status, code, url, detail)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d checked, %d broken\n", len(results), broken)
+	return nil
+}
+
+func printCheckResultsCSV(results []LinkCheckResult) error {
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	w.Write([]string{"slug", "url", "status_code", "redirect_url", "broken", "error"})
+	for _, r := range results {
+		w.Write([]string{
+			r.Slug,
+			r.URL,
+			strconv.Itoa(r.StatusCode),
+			r.RedirectURL,
+			strconv.FormatBool(r.Broken),
+			r.Error,
+		})
+	}
+
+	return nil
+}
+
+// PrintLiveClick outputs one streamed click as a line of text, or as a
+// single-line JSON object for piping.
+func PrintLiveClick(click LiveClick, format OutputFormat) error {
+	if format == OutputFormatJSON {
+		data, err := json.Marshal(click)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
 	}
 
+	location := click.Country
+	if click.City != "" {
+		location = click.City + ", " + click.Country
+	}
+
+	client := strings.TrimSpace(click.Browser + " / " + click.OS)
+	if click.Browser == "" && click.OS == "" {
+		client = "-"
+	}
+
+	fmt.Printf("%s  %-16s  %-10s  %-24s  %-20s  %s\n",
+		click.Timestamp.Local().Format("15:04:05"),
+		click.Slug,
+		orDash(click.Channel),
+		orDash(click.ReferrerHost),
+		orDash(location),
+		client,
+	)
 	return nil
 }
 
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 func PrintFolders(folders []Folder, format OutputFormat) error {
 	switch format {
 	case OutputFormatJSON:
@@ -250,6 +629,109 @@ func printFoldersCSV(folders []Folder) error {
 	return nil
 }
 
+func PrintReports(reports []Report, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		return printJSON(reports)
+	case OutputFormatCSV:
+		return printReportsCSV(reports)
+	default:
+		return printReportsTable(reports)
+	}
+}
+
+func printReportsTable(reports []Report) error {
+	if len(reports) == 0 {
+		fmt.Println("No reports found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSCOPE\tCADENCE\tFORMAT\tDELIVERY\tNEXT RUN\tSTATUS")
+	fmt.Fprintln(w, "--\t----\t-----\t-------\t------\t--------\t--------\t------")
+
+	for _, r := range reports {
+		next := "-"
+		if r.Enabled {
+			next = formatReportTime(r.NextRunAt)
+		}
+
+		status := "never run"
+		switch {
+		case !r.Enabled:
+			status = "paused"
+		case r.LastError != "":
+			status = "failed: " + r.LastError
+		case r.LastRunAt != nil:
+			status = "ok " + formatReportTime(*r.LastRunAt)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.Name, reportScope(r), r.Cadence, r.Format, reportDestination(r), next, status)
+	}
+
+	return w.Flush()
+}
+
+func printReportsCSV(reports []Report) error {
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	w.Write([]string{"id", "name", "scope", "cadence", "timezone", "format", "delivery", "enabled", "next_run_at", "last_run_at", "last_error"})
+	for _, r := range reports {
+		lastRun := ""
+		if r.LastRunAt != nil {
+			lastRun = *r.LastRunAt
+		}
+		w.Write([]string{
+			strconv.FormatInt(r.ID, 10),
+			r.Name,
+			reportScope(r),
+			r.Cadence,
+			r.Timezone,
+			r.Format,
+			reportDestination(r),
+			strconv.FormatBool(r.Enabled),
+			r.NextRunAt,
+			lastRun,
+			r.LastError,
+		})
+	}
+
+	return nil
+}
+
+// reportScope describes what a report covers, such as "tag:campaign".
+func reportScope(r Report) string {
+	switch {
+	case r.Slug != "":
+		return "link:" + r.Slug
+	case r.FolderID != nil:
+		return "folder:" + strconv.FormatInt(*r.FolderID, 10)
+	case r.Tag != "":
+		return "tag:" + r.Tag
+	}
+	return r.Scope
+}
+
+func reportDestination(r Report) string {
+	if r.Delivery == "webhook" {
+		return r.WebhookURL
+	}
+	if r.Directory != "" {
+		return "file:" + r.Directory
+	}
+	return "file"
+}
+
+// formatReportTime shortens an RFC 3339 time to minutes.
+func formatReportTime(s string) string {
+	if len(s) >= 16 {
+		return strings.Replace(s[:16], "T", " ", 1)
+	}
+	return s
+}
+
 func Success(message string) {
 	fmt.Printf("✓ %s\n", message)
 }
@@ -97,6 +97,66 @@ type AppConfig struct {
 	MaxURLLength      int
 	RateLimitPerMin   int
 	StaticDir         string
+
+	// Destination screening
+	URLBlocklistFiles          []string
+	URLBlocklistReloadInterval time.Duration
+	URLScreenResolve           bool
+	URLScreenHomographs        bool
+	URLScreenCacheTTL          time.Duration
+
+	// Link previews
+	PreviewRefreshInterval time.Duration
+	PreviewMaxAge          time.Duration
+
+	// Click location enrichment
+	GeoIPDatabase       string
+	GeoIPReloadInterval time.Duration
+
+	// Redirect cache
+	LinkCacheSize           int
+	LinkCacheTTL            time.Duration
+	ClickCountFlushInterval time.Duration
+
+	// Click ingestion queue
+	ClickQueueSize     int
+	ClickBatchSize     int
+	ClickFlushInterval time.Duration
+
+	// Raw click retention; daily rollups are kept regardless
+	ClickRetentionDays int
+
+	// Conversion tracking appends a click token to destination URLs
+	ConversionTracking bool
+
+	// Referrer channel classification
+	ReferrerChannelFiles          []string
+	ReferrerChannelReloadInterval time.Duration
+
+	// Bot classification
+	BotSignatureFiles          []string
+	BotSignatureReloadInterval time.Duration
+
+	// Logo placed in server-rendered QR codes that ask for one
+	QRLogoFile string
+
+	// Scheduled stats reports
+	ReportDir           string
+	ReportCheckInterval time.Duration
+
+	// Outbound webhooks
+	WebhookPollInterval time.Duration
+	WebhookMaxAttempts  int
+	WebhookConcurrency  int
+	WebhookTimeout      time.Duration
+	WebhookLogRetention time.Duration
+
+	// How often links whose expiry passed are published
+	LinkExpiryCheckInterval time.Duration
+
+	// Destination health checks
+	LinkCheckInterval    time.Duration
+	LinkCheckConcurrency int
 }
 
 // Load reads configuration from environment variables.
@@ -130,6 +190,52 @@ func Load() (*Config, error) {
 			MaxURLLength:     getEnvInt("MAX_URL_LENGTH", 2048),
 			RateLimitPerMin:  getEnvInt("RATE_LIMIT_PER_MIN", 100),
 			StaticDir:        getEnv("STATIC_DIR", ""),
+
+			URLBlocklistFiles:          getEnvList("URL_BLOCKLIST_FILES", nil),
+			URLBlocklistReloadInterval: getEnvDuration("URL_BLOCKLIST_RELOAD_INTERVAL", time.Minute),
+			URLScreenResolve:           getEnvBool("URL_SCREEN_RESOLVE", true),
+			URLScreenHomographs:        getEnvBool("URL_SCREEN_HOMOGRAPHS", true),
+			URLScreenCacheTTL:          getEnvDuration("URL_SCREEN_CACHE_TTL", 10*time.Minute),
+
+			PreviewRefreshInterval: getEnvDuration("PREVIEW_REFRESH_INTERVAL", time.Hour),
+			PreviewMaxAge:          getEnvDuration("PREVIEW_MAX_AGE", 7*24*time.Hour),
+
+			GeoIPDatabase:       getEnv("GEOIP_DATABASE", ""),
+			GeoIPReloadInterval: getEnvDuration("GEOIP_RELOAD_INTERVAL", time.Minute),
+
+			LinkCacheSize:           getEnvInt("LINK_CACHE_SIZE", 10000),
+			LinkCacheTTL:            getEnvDuration("LINK_CACHE_TTL", time.Minute),
+			ClickCountFlushInterval: getEnvDuration("CLICK_COUNT_FLUSH_INTERVAL", time.Second),
+
+			ClickQueueSize:     getEnvInt("CLICK_QUEUE_SIZE", 10000),
+			ClickBatchSize:     getEnvInt("CLICK_BATCH_SIZE", 500),
+			ClickFlushInterval: getEnvDuration("CLICK_FLUSH_INTERVAL", time.Second),
+
+			ClickRetentionDays: getEnvInt("CLICK_RETENTION_DAYS", 0),
+
+			ConversionTracking: getEnvBool("CONVERSION_TRACKING", false),
+
+			ReferrerChannelFiles:          getEnvList("REFERRER_CHANNEL_FILES", nil),
+			ReferrerChannelReloadInterval: getEnvDuration("REFERRER_CHANNEL_RELOAD_INTERVAL", time.Minute),
+
+			BotSignatureFiles:          getEnvList("BOT_SIGNATURE_FILES", nil),
+			BotSignatureReloadInterval: getEnvDuration("BOT_SIGNATURE_RELOAD_INTERVAL", time.Minute),
+
+			QRLogoFile: getEnv("QR_LOGO_FILE", ""),
+
+			ReportDir:           getEnv("REPORT_DIR", ""),
+			ReportCheckInterval: getEnvDuration("REPORT_CHECK_INTERVAL", time.Minute),
+
+			WebhookPollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			WebhookConcurrency:  getEnvInt("WEBHOOK_CONCURRENCY", 4),
+			WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			WebhookLogRetention: getEnvDuration("WEBHOOK_LOG_RETENTION", 7*24*time.Hour),
+
+			LinkExpiryCheckInterval: getEnvDuration("LINK_EXPIRY_CHECK_INTERVAL", 30*time.Second),
+
+			LinkCheckInterval:    getEnvDuration("LINK_CHECK_INTERVAL", 24*time.Hour),
+			LinkCheckConcurrency: getEnvInt("LINK_CHECK_CONCURRENCY", 4),
 		},
 	}
 
@@ -154,6 +260,9 @@ func (c *Config) Validate() error {
 	if c.App.SlugLength < 4 || c.App.SlugLength > 32 {
 		return fmt.Errorf("SLUG_LENGTH must be between 4 and 32")
 	}
+	if c.App.ClickRetentionDays < 0 {
+		return fmt.Errorf("CLICK_RETENTION_DAYS must not be negative")
+	}
 	return nil
 }
 
@@ -0,0 +1,148 @@
+package analytics
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+//go:embed bots.txt
+var defaultBotList string
+
+// builtinBots classifies with the built-in list alone.
+var builtinBots = mustBotClassifier()
+
+// BotClassifier assigns User-Agents to bot classes using a signature list.
+// The built-in list can be extended or overridden with local files, which
+// are reloadable.
+type BotClassifier struct {
+	paths []string
+
+	mu         sync.RWMutex
+	signatures []botSignature
+}
+
+// botSignature is a lower-cased User-Agent substring and its class.
+type botSignature struct {
+	signature string
+	class     string
+}
+
+// NewBotClassifier builds a classifier from the built-in list plus the
+// given files. Missing files are an error.
+func NewBotClassifier(paths ...string) (*BotClassifier, error) {
+	c := &BotClassifier{paths: paths}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func mustBotClassifier() *BotClassifier {
+	c, err := NewBotClassifier()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Paths returns the extra files backing this classifier.
+func (c *BotClassifier) Paths() []string {
+	return c.paths
+}
+
+// Reload re-reads the built-in list and all files. On error the previous
+// entries are kept.
+func (c *BotClassifier) Reload() error {
+	classes := make(map[string]string)
+
+	if err := loadBotList(strings.NewReader(defaultBotList), "built-in list", classes); err != nil {
+		return err
+	}
+
+	for _, path := range c.paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open bot signature list %s: %w", path, err)
+		}
+		err = loadBotList(file, path, classes)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Longest signature first, so the most specific match wins.
+	sorted := make([]botSignature, 0, len(classes))
+	for signature, class := range classes {
+		sorted = append(sorted, botSignature{signature: signature, class: class})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].signature) != len(sorted[j].signature) {
+			return len(sorted[i].signature) > len(sorted[j].signature)
+		}
+		return sorted[i].signature < sorted[j].signature
+	})
+
+	c.mu.Lock()
+	c.signatures = sorted
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Classify returns the bot class of userAgent, or "" if it looks like a
+// person's browser.
+func (c *BotClassifier) Classify(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+	ua := strings.ToLower(userAgent)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, s := range c.signatures {
+		if strings.Contains(ua, s.signature) {
+			return s.class
+		}
+	}
+	return ""
+}
+
+func loadBotList(r io.Reader, name string, classes map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if idx := strings.Index(text, "#"); idx != -1 {
+			text = text[:idx]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("%s:%d: expected \"<signature> <class>\"", name, line)
+		}
+
+		signature, class := strings.ToLower(fields[0]), strings.ToLower(fields[1])
+		if !domain.IsBotClass(class) {
+			return fmt.Errorf("%s:%d: unknown bot class %q", name, line, class)
+		}
+		classes[signature] = class
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read bot signature list %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package analytics
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+//go:embed channels.txt
+var defaultChannelList string
+
+// ChannelClassifier assigns referrers to channels using a host list. The
+// built-in list can be extended or overridden with local files, which are
+// reloadable.
+type ChannelClassifier struct {
+	paths    []string
+	internal map[string]bool
+
+	mu       sync.RWMutex
+	exact    map[string]string
+	prefixes []channelPrefix
+}
+
+// channelPrefix is a "name.*" entry, stored as "name.".
+type channelPrefix struct {
+	prefix  string
+	channel string
+}
+
+// NewChannelClassifier builds a classifier from the built-in list plus the
+// given files. internalHosts (e.g. the short link domains) classify as
+// internal. Missing files are an error.
+func NewChannelClassifier(internalHosts []string, paths ...string) (*ChannelClassifier, error) {
+	c := &ChannelClassifier{paths: paths, internal: make(map[string]bool)}
+	for _, host := range internalHosts {
+		if host = normalizeHost(host); host != "" {
+			c.internal[host] = true
+		}
+	}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Paths returns the extra files backing this classifier.
+func (c *ChannelClassifier) Paths() []string {
+	return c.paths
+}
+
+// Reload re-reads the built-in list and all files. On error the previous
+// entries are kept.
+func (c *ChannelClassifier) Reload() error {
+	exact := make(map[string]string)
+	prefixes := make(map[string]string)
+
+	if err := loadChannelList(strings.NewReader(defaultChannelList), "built-in list", exact, prefixes); err != nil {
+		return err
+	}
+
+	for _, path := range c.paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open referrer channel list %s: %w", path, err)
+		}
+		err = loadChannelList(file, path, exact, prefixes)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Longest prefix first so overlapping wildcard entries resolve predictably.
+	sorted := make([]channelPrefix, 0, len(prefixes))
+	for prefix, channel := range prefixes {
+		sorted = append(sorted, channelPrefix{prefix: prefix, channel: channel})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].prefix) != len(sorted[j].prefix) {
+			return len(sorted[i].prefix) > len(sorted[j].prefix)
+		}
+		return sorted[i].prefix < sorted[j].prefix
+	})
+
+	c.mu.Lock()
+	c.exact = exact
+	c.prefixes = sorted
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Classify returns the channel and host for a normalized referrer.
+func (c *ChannelClassifier) Classify(referrer string) (channel, host string) {
+	if referrer == "" || referrer == "direct" {
+		return domain.ChannelDirect, ""
+	}
+
+	parsed, err := url.Parse(referrer)
+	if err != nil || parsed.Hostname() == "" {
+		return domain.ChannelOther, ""
+	}
+	host = normalizeHost(parsed.Hostname())
+
+	for h := host; h != ""; {
+		if c.internal[h] {
+			return domain.ChannelInternal, host
+		}
+		idx := strings.Index(h, ".")
+		if idx == -1 {
+			break
+		}
+		h = h[idx+1:]
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Try the host and each parent domain, most specific first.
+	for h := host; h != ""; {
+		if channel, ok := c.exact[h]; ok {
+			return channel, host
+		}
+		for _, p := range c.prefixes {
+			if strings.HasPrefix(h, p.prefix) {
+				return p.channel, host
+			}
+		}
+		idx := strings.Index(h, ".")
+		if idx == -1 {
+			break
+		}
+		h = h[idx+1:]
+	}
+
+	return domain.ChannelOther, host
+}
+
+func loadChannelList(r io.Reader, name string, exact, prefixes map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if idx := strings.Index(text, "#"); idx != -1 {
+			text = text[:idx]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("%s:%d: expected \"<host> <channel>\"", name, line)
+		}
+
+		host, channel := strings.ToLower(fields[0]), strings.ToLower(fields[1])
+		if !domain.IsReferrerChannel(channel) || channel == domain.ChannelDirect || channel == domain.ChannelInternal {
+			return fmt.Errorf("%s:%d: unknown channel %q", name, line, channel)
+		}
+
+		if strings.HasSuffix(host, ".*") {
+			prefixes[strings.TrimSuffix(host, "*")] = channel
+			continue
+		}
+		exact[normalizeHost(host)] = channel
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read referrer channel list %s: %w", name, err)
+	}
+	return nil
+}
+
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+	return strings.TrimPrefix(host, "www.")
+}
@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"regexp"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// goalPattern limits goal names to short identifiers such as "signup" or
+// "checkout.completed".
+var goalPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// SetConversionTracking turns conversion tracking on or off. When on,
+// ClickToken hands out tokens for redirects to append to the destination.
+func (s *Service) SetConversionTracking(enabled bool) {
+	s.conversions = enabled
+}
+
+// ClickToken returns a new token identifying ev for conversion tracking,
+// or "" when conversions are off, ev will not be recorded or comes from a
+// bot.
+func (s *Service) ClickToken(ev ClickEvent) string {
+	if !s.conversions || !s.tracks(ev) || s.bots.Classify(ev.UserAgent) != "" {
+		return ""
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// RecordConversion records that the click with the given token reached
+// goal, or DefaultConversionGoal if goal is empty. Reporting the same goal
+// for a click again is not an error and returns the first conversion.
+func (s *Service) RecordConversion(ctx context.Context, token, goal string) (*domain.Conversion, error) {
+	if token == "" {
+		return nil, domain.NewValidationError("click", "click token is required")
+	}
+	if goal == "" {
+		goal = domain.DefaultConversionGoal
+	}
+	if !goalPattern.MatchString(goal) {
+		return nil, domain.NewValidationError("goal", "goal must be up to 64 letters, digits, '_', '.' or '-'")
+	}
+
+	conv := &domain.Conversion{
+		Goal:      goal,
+		Timestamp: time.Now().UTC(),
+	}
+	err := s.clickRepo.RecordConversion(ctx, conv, token)
+	// The click may still be waiting for the batch writer when the
+	// destination page reports it straight away.
+	if err == domain.ErrClickNotFound && s.ingest.awaitToken(ctx, token) {
+		err = s.clickRepo.RecordConversion(ctx, conv, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
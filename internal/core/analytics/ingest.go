@@ -0,0 +1,255 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/event"
+)
+
+// ClickEvent is a click as seen by the redirect handler, before enrichment.
+type ClickEvent struct {
+	Link      *domain.Link
+	Timestamp time.Time
+	IP        string
+	UserAgent string
+	Referrer  string
+	// Token is the click's conversion token from ClickToken, if any.
+	Token string
+	// DoNotTrack is set when the request carried DNT: 1 or Sec-GPC: 1.
+	DoNotTrack bool
+	// Source is one of the domain.Source* values.
+	Source string
+}
+
+// IngestStats reports the state of the click ingestion queue. QueueDepth
+// counts clicks accepted but not yet written, including the batch in flight.
+type IngestStats struct {
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Written       uint64 `json:"written"`
+	Dropped       uint64 `json:"dropped"`
+	Failed        uint64 `json:"failed"`
+}
+
+// ingestQueue buffers clicks between redirects and the batch writer.
+type ingestQueue struct {
+	mu     sync.RWMutex
+	events chan ClickEvent
+	closed bool
+	done   chan struct{}
+
+	// tokens holds a channel per queued click with a conversion token,
+	// closed once the click's batch has been written, so conversions
+	// reported before then can wait for it.
+	tokensMu sync.Mutex
+	tokens   map[string]chan struct{}
+
+	pending atomic.Int64
+	written atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// StartIngester starts the batch writer behind Enqueue. Up to queueSize
+// clicks are buffered; they are written in one transaction whenever
+// batchSize have queued up or flushInterval has passed. Call Shutdown to
+// flush what is left.
+func (s *Service) StartIngester(queueSize, batchSize int, flushInterval time.Duration) {
+	if queueSize <= 0 {
+		queueSize = 10000
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	s.ingest.mu.Lock()
+	defer s.ingest.mu.Unlock()
+	if s.ingest.events != nil {
+		return
+	}
+	s.ingest.events = make(chan ClickEvent, queueSize)
+	s.ingest.done = make(chan struct{})
+
+	go s.runIngester(s.ingest.events, batchSize, flushInterval)
+}
+
+// Enqueue queues a click for the batch writer without blocking. It reports
+// false if the click was dropped because the queue is full or shut down.
+// Without a running ingester the click is written synchronously. Clicks the
+// link's privacy settings exclude are skipped and reported as queued.
+func (s *Service) Enqueue(ev ClickEvent) bool {
+	if !s.tracks(ev) {
+		return true
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now().UTC()
+	}
+
+	s.ingest.mu.RLock()
+	defer s.ingest.mu.RUnlock()
+
+	if s.ingest.events == nil {
+		return s.RecordClick(context.Background(), ev) == nil
+	}
+	if s.ingest.closed {
+		s.ingest.dropped.Add(1)
+		return false
+	}
+
+	// The capacity covers the batch being written as well as the channel,
+	// so a slow database cannot buffer more than queueSize clicks and the
+	// send below never blocks.
+	if s.ingest.pending.Add(1) > int64(cap(s.ingest.events)) {
+		s.ingest.pending.Add(-1)
+		s.ingest.dropped.Add(1)
+		return false
+	}
+
+	// Registered before the send so the writer cannot finish first.
+	s.ingest.addToken(ev.Token)
+	s.ingest.events <- ev
+	return true
+}
+
+// addToken marks a conversion token as queued but not yet written.
+func (q *ingestQueue) addToken(token string) {
+	if token == "" {
+		return
+	}
+	q.tokensMu.Lock()
+	defer q.tokensMu.Unlock()
+	if q.tokens == nil {
+		q.tokens = make(map[string]chan struct{})
+	}
+	q.tokens[token] = make(chan struct{})
+}
+
+// doneTokens releases anyone waiting on the batch's conversion tokens.
+func (q *ingestQueue) doneTokens(batch []ClickEvent) {
+	q.tokensMu.Lock()
+	defer q.tokensMu.Unlock()
+	for _, ev := range batch {
+		if ch, ok := q.tokens[ev.Token]; ok {
+			close(ch)
+			delete(q.tokens, ev.Token)
+		}
+	}
+}
+
+// awaitToken waits until the click with the given conversion token has
+// been written, if it is still queued. It reports whether it waited.
+func (q *ingestQueue) awaitToken(ctx context.Context, token string) bool {
+	q.tokensMu.Lock()
+	ch, ok := q.tokens[token]
+	q.tokensMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	select {
+	case <-ch:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Shutdown stops accepting clicks and waits for queued ones to be written.
+// It returns ctx.Err() if ctx ends first; the remaining clicks are lost.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.ingest.mu.Lock()
+	if s.ingest.events == nil || s.ingest.closed {
+		s.ingest.mu.Unlock()
+		return nil
+	}
+	s.ingest.closed = true
+	close(s.ingest.events)
+	s.ingest.mu.Unlock()
+
+	select {
+	case <-s.ingest.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IngestStats returns the current queue depth and lifetime counters.
+func (s *Service) IngestStats() IngestStats {
+	s.ingest.mu.RLock()
+	events := s.ingest.events
+	s.ingest.mu.RUnlock()
+
+	return IngestStats{
+		QueueDepth:    int(s.ingest.pending.Load()),
+		QueueCapacity: cap(events),
+		Written:       s.ingest.written.Load(),
+		Dropped:       s.ingest.dropped.Load(),
+		Failed:        s.ingest.failed.Load(),
+	}
+}
+
+func (s *Service) runIngester(events <-chan ClickEvent, batchSize int, flushInterval time.Duration) {
+	defer close(s.ingest.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ClickEvent, 0, batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			s.writeBatch(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, ev)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// writeBatch enriches and stores a batch. The request that produced each
+// click is long gone, so writes are not tied to any request context.
+func (s *Service) writeBatch(batch []ClickEvent) {
+	ctx := context.Background()
+
+	clicks := make([]*domain.Click, len(batch))
+	for i, ev := range batch {
+		clicks[i] = s.newClick(ctx, ev)
+	}
+
+	defer s.ingest.pending.Add(-int64(len(clicks)))
+	defer s.ingest.doneTokens(batch)
+
+	if err := s.clickRepo.RecordBatch(ctx, clicks); err != nil {
+		s.ingest.failed.Add(uint64(len(clicks)))
+		return
+	}
+	s.ingest.written.Add(uint64(len(clicks)))
+
+	recorded := make([]event.ClickRecorded, len(clicks))
+	for i, click := range clicks {
+		recorded[i] = event.ClickRecorded{Link: batch[i].Link, Click: click}
+		event.Publish(ctx, s.events, recorded[i])
+	}
+	event.Publish(ctx, s.events, event.ClicksRecorded{Clicks: recorded})
+}
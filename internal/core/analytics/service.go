@@ -4,45 +4,153 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/event"
 	"github.com/aftaab/trelay/internal/core/port"
 )
 
+// GeoLocator resolves a client IP address to a coarse location.
+type GeoLocator interface {
+	Lookup(ip string) domain.GeoLocation
+}
+
 // Service handles analytics and click tracking.
 type Service struct {
 	clickRepo       port.ClickRepository
 	anonymizeIP     bool
 	enabled         bool
+	geo             GeoLocator
+	channels        *ChannelClassifier
+	bots            *BotClassifier
+	salts           *saltRotator
+	ingest          ingestQueue
+	stream          clickStream
+
+	conversions bool
+
+	events *event.Bus
 }
 
-// NewService creates a new analytics service.
-func NewService(clickRepo port.ClickRepository, anonymizeIP, enabled bool) *Service {
-	return &Service{
+// NewService creates a new analytics service. configRepo persists the daily
+// visitor salt. geo may be nil to skip location enrichment, and channels and
+// bots may be nil to use the built-in referrer channel and bot lists.
+func NewService(clickRepo port.ClickRepository, configRepo port.ConfigRepository, anonymizeIP, enabled bool, geo GeoLocator, channels *ChannelClassifier, bots *BotClassifier) *Service {
+	if channels == nil {
+		channels, _ = NewChannelClassifier(nil)
+	}
+	if bots == nil {
+		bots = builtinBots
+	}
+	s := &Service{
 		clickRepo:   clickRepo,
 		anonymizeIP: anonymizeIP,
 		enabled:     enabled,
+		geo:         geo,
+		channels:    channels,
+		bots:        bots,
+		salts:       &saltRotator{store: configRepo},
 	}
+	s.SetEventBus(event.NewBus())
+	return s
 }
 
-// RecordClick records a click event for a link.
-func (s *Service) RecordClick(ctx context.Context, linkID int64, ip, userAgent, referrer string) error {
-	if !s.enabled {
+// RecordClick records a click event for a link, writing it immediately.
+// Redirects should use Enqueue instead.
+func (s *Service) RecordClick(ctx context.Context, ev ClickEvent) error {
+	if !s.tracks(ev) {
 		return nil
 	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now().UTC()
+	}
+
+	click := s.newClick(ctx, ev)
+	if err := s.clickRepo.Record(ctx, click); err != nil {
+		return err
+	}
+
+	recorded := event.ClickRecorded{Link: ev.Link, Click: click}
+	event.Publish(ctx, s.events, recorded)
+	event.Publish(ctx, s.events, event.ClicksRecorded{Clicks: []event.ClickRecorded{recorded}})
+	return nil
+}
+
+// SetEventBus publishes recorded clicks on bus, replacing the service's
+// own, and subscribes live click streams to them there. Call it before any
+// clicks are recorded.
+func (s *Service) SetEventBus(bus *event.Bus) {
+	s.events = bus
+	event.Subscribe(bus, s.stream.clickRecorded)
+}
+
+// tracks reports whether ev may be recorded at all, given the global switch
+// and the link's privacy settings.
+func (s *Service) tracks(ev ClickEvent) bool {
+	privacy := ev.Link.Privacy
+	return s.enabled && !privacy.DisableTracking && !(privacy.HonorDNT && ev.DoNotTrack)
+}
+
+// newClick enriches a raw click event into the row that is stored, leaving
+// out what the link's privacy settings drop.
+func (s *Service) newClick(ctx context.Context, ev ClickEvent) *domain.Click {
+	ip, userAgent, referrer, linkID := ev.IP, ev.UserAgent, ev.Referrer, ev.Link.ID
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	salt := s.salts.get(ctx, ev.Timestamp)
+
+	// Bots are classified before a dropped User-Agent is discarded, so they
+	// stay out of human stats either way.
+	bot := s.bots.Classify(userAgent)
+	if ev.Link.Privacy.DropUserAgent {
+		userAgent = ""
+	}
+	ua := ParseUserAgent(userAgent)
+
+	// A dropped referrer is unknown rather than direct, so it has no channel.
+	var channel, referrerHost string
+	if ev.Link.Privacy.DropReferrer {
+		referrer = ""
+	} else {
+		referrer = normalizeReferrer(referrer)
+		channel, referrerHost = s.channels.Classify(referrer)
+	}
 
 	click := &domain.Click{
-		LinkID:     linkID,
-		Timestamp:  time.Now().UTC(),
-		Referrer:   normalizeReferrer(referrer),
-		DeviceHash: hashDeviceInfo(userAgent),
-		UserAgent:  userAgent,
-		IPHash:     s.hashIP(ip),
+		LinkID:         linkID,
+		Timestamp:      ev.Timestamp,
+		Referrer:       referrer,
+		ReferrerHost:   referrerHost,
+		Channel:        channel,
+		DeviceHash:     hashDeviceInfo(ua.Device),
+		UserAgent:      userAgent,
+		IPHash:         s.hashIP(salt, ip),
+		VisitorID:      visitorID(salt, linkID, ip, userAgent),
+		Browser:        ua.Browser,
+		BrowserVersion: ua.BrowserVersion,
+		OS:             ua.OS,
+		OSVersion:      ua.OSVersion,
+		DeviceType:     ua.Device,
+		Token:          ev.Token,
+		Bot:            bot,
+		Source:         ev.Source,
+	}
+	if bot != "" {
+		click.DeviceType = DeviceBot
+		click.DeviceHash = hashDeviceInfo(DeviceBot)
+	}
+
+	// Resolve the location from the full address; only salted hashes are stored.
+	if s.geo != nil {
+		loc := s.geo.Lookup(ip)
+		click.Country, click.Region, click.City = loc.Country, loc.Region, loc.City
 	}
 
-	return s.clickRepo.Record(ctx, click)
+	return click
 }
 
 // GetStats retrieves analytics for a link.
@@ -50,37 +158,238 @@ func (s *Service) GetStats(ctx context.Context, linkID int64, filter domain.Stat
 	return s.clickRepo.GetStatsByLinkID(ctx, linkID, filter)
 }
 
+// Compare fills in stats.Comparison with the same stats for the window
+// selected by mode. The filter must have a start date; the current window
+// runs from it to the end date or now.
+func (s *Service) Compare(ctx context.Context, linkID int64, stats *domain.ClickStats, filter domain.StatsFilter, mode domain.StatsCompare) error {
+	if filter.StartDate == nil {
+		return domain.NewValidationError("compare", "compare needs a period or from date")
+	}
+	end := time.Now().UTC()
+	if filter.EndDate != nil && filter.EndDate.Before(end) {
+		end = *filter.EndDate
+	}
+
+	prevStart, prevEnd := mode.Window(*filter.StartDate, end)
+	prevFilter := filter
+	prevFilter.StartDate, prevFilter.EndDate = &prevStart, &prevEnd
+
+	previous, err := s.clickRepo.GetStatsByLinkID(ctx, linkID, prevFilter)
+	if err != nil {
+		return err
+	}
+
+	// The deltas look up each current entry among the previous window's
+	// wider top list, so one that was not in its top 10 still compares.
+	prevReferrers, err := s.clickRepo.GetTopReferrers(ctx, linkID, 100, prevFilter)
+	if err != nil {
+		return err
+	}
+	referrerClicks := make(map[string]int64, len(prevReferrers))
+	for _, r := range prevReferrers {
+		referrerClicks[r.Referrer] = r.Clicks
+	}
+	deviceClicks := make(map[string]int64, len(previous.DeviceStats))
+	for _, d := range previous.DeviceStats {
+		deviceClicks[d.DeviceType] = d.Clicks
+	}
+
+	comparison := &domain.StatsComparison{
+		Mode:           mode,
+		StartDate:      prevStart,
+		EndDate:        prevEnd,
+		Previous:       previous,
+		TotalClicks:    domain.NewMetricDelta("", stats.TotalClicks, previous.TotalClicks),
+		UniqueVisitors: domain.NewMetricDelta("", stats.UniqueVisitors, previous.UniqueVisitors),
+		TopReferrers:   make([]domain.MetricDelta, 0, len(stats.TopReferrers)),
+		DeviceStats:    make([]domain.MetricDelta, 0, len(stats.DeviceStats)),
+	}
+	for _, r := range stats.TopReferrers {
+		comparison.TopReferrers = append(comparison.TopReferrers, domain.NewMetricDelta(r.Referrer, r.Clicks, referrerClicks[r.Referrer]))
+	}
+	for _, d := range stats.DeviceStats {
+		comparison.DeviceStats = append(comparison.DeviceStats, domain.NewMetricDelta(d.DeviceType, d.Clicks, deviceClicks[d.DeviceType]))
+		delete(deviceClicks, d.DeviceType)
+	}
+	// Device classes are few, so ones that dropped to zero are listed too.
+	for _, d := range previous.DeviceStats {
+		if clicks, ok := deviceClicks[d.DeviceType]; ok {
+			comparison.DeviceStats = append(comparison.DeviceStats, domain.NewMetricDelta(d.DeviceType, 0, clicks))
+		}
+	}
+
+	stats.Comparison = comparison
+	return nil
+}
+
+// GetWorkspaceStats retrieves analytics across all links, or the links in
+// the filter's folder or with its tag.
+func (s *Service) GetWorkspaceStats(ctx context.Context, days, limit int, filter domain.StatsFilter) (*domain.WorkspaceStats, error) {
+	if days <= 0 {
+		days = 30
+	}
+	if days > 365 {
+		days = 365
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return s.clickRepo.GetWorkspaceStats(ctx, days, limit, filter)
+}
+
+// ExportClicks retrieves up to limit raw clicks recorded after cursor,
+// oldest first, and the cursor of the next page, which is 0 after the last
+// one. Clicks pruned by the retention policy are no longer available.
+func (s *Service) ExportClicks(ctx context.Context, linkID int64, filter domain.StatsFilter, cursor int64, limit int) ([]*domain.Click, int64, error) {
+	if limit <= 0 {
+		limit = 1000
+	}
+	if limit > 10000 {
+		limit = 10000
+	}
+
+	clicks, err := s.clickRepo.GetByLinkID(ctx, linkID, filter, cursor, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var next int64
+	if len(clicks) == limit {
+		next = clicks[len(clicks)-1].ID
+	}
+	return clicks, next, nil
+}
+
+// GetClicksByHour retrieves hourly click data.
+func (s *Service) GetClicksByHour(ctx context.Context, linkID int64, hours int, filter domain.StatsFilter) ([]domain.HourStats, error) {
+	if hours <= 0 {
+		hours = 24
+	}
+	if hours > 168 {
+		hours = 168
+	}
+	return s.clickRepo.GetClicksByHour(ctx, linkID, hours, filter)
+}
+
 // GetClicksByDay retrieves daily click data.
-func (s *Service) GetClicksByDay(ctx context.Context, linkID int64, days int) ([]domain.DayStats, error) {
+func (s *Service) GetClicksByDay(ctx context.Context, linkID int64, days int, filter domain.StatsFilter) ([]domain.DayStats, error) {
 	if days <= 0 {
 		days = 30
 	}
 	if days > 365 {
 		days = 365
 	}
-	return s.clickRepo.GetClicksByDay(ctx, linkID, days)
+	return s.clickRepo.GetClicksByDay(ctx, linkID, days, filter)
 }
 
 // GetClicksByMonth retrieves monthly click data.
-func (s *Service) GetClicksByMonth(ctx context.Context, linkID int64, months int) ([]domain.MonthStats, error) {
+func (s *Service) GetClicksByMonth(ctx context.Context, linkID int64, months int, filter domain.StatsFilter) ([]domain.MonthStats, error) {
 	if months <= 0 {
 		months = 12
 	}
 	if months > 24 {
 		months = 24
 	}
-	return s.clickRepo.GetClicksByMonth(ctx, linkID, months)
+	return s.clickRepo.GetClicksByMonth(ctx, linkID, months, filter)
 }
 
 // GetTopReferrers retrieves top referrer sources.
-func (s *Service) GetTopReferrers(ctx context.Context, linkID int64, limit int) ([]domain.ReferrerStats, error) {
+func (s *Service) GetTopReferrers(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.ReferrerStats, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return s.clickRepo.GetTopReferrers(ctx, linkID, limit, filter)
+}
+
+// GetGeoStats retrieves the top countries and cities for a link.
+func (s *Service) GetGeoStats(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) (*domain.GeoStats, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 	if limit > 100 {
 		limit = 100
 	}
-	return s.clickRepo.GetTopReferrers(ctx, linkID, limit)
+
+	countries, err := s.clickRepo.GetTopCountries(ctx, linkID, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cities, err := s.clickRepo.GetTopCities(ctx, linkID, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.GeoStats{
+		Countries: countries,
+		Cities:    cities,
+	}
+	if stats.Countries == nil {
+		stats.Countries = []domain.CountryStats{}
+	}
+	if stats.Cities == nil {
+		stats.Cities = []domain.CityStats{}
+	}
+	return stats, nil
+}
+
+// GetChannels retrieves clicks per referrer channel with the top hosts of each.
+func (s *Service) GetChannels(ctx context.Context, linkID int64, hostsPerChannel int, filter domain.StatsFilter) ([]domain.ChannelStats, error) {
+	if hostsPerChannel <= 0 {
+		hostsPerChannel = 5
+	}
+	if hostsPerChannel > 50 {
+		hostsPerChannel = 50
+	}
+
+	stats, err := s.clickRepo.GetChannels(ctx, linkID, hostsPerChannel, filter)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = []domain.ChannelStats{}
+	}
+	return stats, nil
+}
+
+// retentionTick is how often RunRetention prunes. Pruning works in whole
+// days, so there is little to gain from running it more often.
+const retentionTick = time.Hour
+
+// PruneClicks deletes raw clicks from days that ended more than retention
+// ago. Their daily rollups are kept, so totals, referrers, devices and
+// countries still cover them.
+func (s *Service) PruneClicks(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := time.Now().UTC().Add(-retention)
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
+	return s.clickRepo.PruneClicks(ctx, cutoff)
+}
+
+// RunRetention prunes raw clicks older than retention now and then hourly
+// until ctx is done. A zero retention keeps raw clicks forever.
+func (s *Service) RunRetention(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(retentionTick)
+	defer ticker.Stop()
+
+	for {
+		_, _ = s.PruneClicks(ctx, retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 // DeleteStats removes all analytics for a link (GDPR compliance).
@@ -88,8 +397,9 @@ func (s *Service) DeleteStats(ctx context.Context, linkID int64) error {
 	return s.clickRepo.DeleteByLinkID(ctx, linkID)
 }
 
-// hashIP creates an anonymized hash of an IP address.
-func (s *Service) hashIP(ip string) string {
+// hashIP creates an anonymized hash of an IP address. The daily salt keeps
+// the hash from being reversed by enumerating the truncated address space.
+func (s *Service) hashIP(salt []byte, ip string) string {
 	if !s.anonymizeIP || ip == "" {
 		return ""
 	}
@@ -101,38 +411,23 @@ func (s *Service) hashIP(ip string) string {
 		ip = strings.Join(parts[:3], ".") + ".0"
 	}
 
-	hash := sha256.Sum256([]byte(ip))
-	return hex.EncodeToString(hash[:8])
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(ip))
+	return hex.EncodeToString(h.Sum(nil)[:8])
 }
 
-// hashDeviceInfo creates a privacy-preserving device fingerprint.
-func hashDeviceInfo(userAgent string) string {
-	if userAgent == "" {
+// hashDeviceInfo creates a privacy-preserving device fingerprint from the
+// general device class, without unique identifiers.
+func hashDeviceInfo(deviceType string) string {
+	if deviceType == "" {
 		return ""
 	}
 
-	// Extract general device type without unique identifiers
-	deviceType := extractDeviceType(userAgent)
 	hash := sha256.Sum256([]byte(deviceType))
 	return hex.EncodeToString(hash[:8])
 }
 
-// extractDeviceType determines general device category from user agent.
-func extractDeviceType(userAgent string) string {
-	ua := strings.ToLower(userAgent)
-
-	switch {
-	case strings.Contains(ua, "mobile") || strings.Contains(ua, "android"):
-		return "mobile"
-	case strings.Contains(ua, "tablet") || strings.Contains(ua, "ipad"):
-		return "tablet"
-	case strings.Contains(ua, "bot") || strings.Contains(ua, "crawler") || strings.Contains(ua, "spider"):
-		return "bot"
-	default:
-		return "desktop"
-	}
-}
-
 // normalizeReferrer cleans up referrer URL for storage.
 func normalizeReferrer(referrer string) string {
 	if referrer == "" {
@@ -155,19 +450,8 @@ func normalizeReferrer(referrer string) string {
 	return referrer
 }
 
-// IsBot checks if a user agent appears to be a bot.
+// IsBot checks if a user agent appears to be a bot, using the built-in
+// signature list.
 func IsBot(userAgent string) bool {
-	ua := strings.ToLower(userAgent)
-	botIndicators := []string{
-		"bot", "crawler", "spider", "slurp", "facebook",
-		"twitter", "linkedin", "pinterest", "whatsapp",
-		"telegram", "preview", "fetch", "curl", "wget",
-	}
-
-	for _, indicator := range botIndicators {
-		if strings.Contains(ua, indicator) {
-			return true
-		}
-	}
-	return false
+	return builtinBots.Classify(userAgent) != ""
 }
@@ -0,0 +1,99 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/event"
+)
+
+// streamBuffer is how many clicks a subscriber may fall behind before
+// further clicks are skipped for it.
+const streamBuffer = 64
+
+// clickStream fans recorded clicks out to live subscribers. Publishing never
+// blocks: a subscriber that stops reading misses clicks rather than slowing
+// down ingestion.
+type clickStream struct {
+	mu     sync.Mutex
+	subs   map[*clickSubscriber]struct{}
+	closed bool
+}
+
+type clickSubscriber struct {
+	filter domain.ClickStreamFilter
+	ch     chan domain.LiveClick
+	once   sync.Once
+}
+
+// close closes the subscriber's channel. The stream lock must be held so
+// that publish never sends on a closed channel.
+func (sub *clickSubscriber) close() {
+	sub.once.Do(func() { close(sub.ch) })
+}
+
+// SubscribeClicks returns a channel of clicks matching filter as they are
+// recorded, and a function that ends the subscription and closes the
+// channel.
+func (s *Service) SubscribeClicks(filter domain.ClickStreamFilter) (<-chan domain.LiveClick, func()) {
+	sub := &clickSubscriber{
+		filter: filter,
+		ch:     make(chan domain.LiveClick, streamBuffer),
+	}
+
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+
+	if s.stream.closed {
+		sub.close()
+		return sub.ch, func() {}
+	}
+	if s.stream.subs == nil {
+		s.stream.subs = make(map[*clickSubscriber]struct{})
+	}
+	s.stream.subs[sub] = struct{}{}
+
+	cancel := func() {
+		s.stream.mu.Lock()
+		defer s.stream.mu.Unlock()
+		delete(s.stream.subs, sub)
+		sub.close()
+	}
+	return sub.ch, cancel
+}
+
+// CloseClickStreams ends every subscription and refuses new ones, so
+// streaming responses finish ahead of server shutdown.
+func (s *Service) CloseClickStreams() {
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+
+	s.stream.closed = true
+	for sub := range s.stream.subs {
+		delete(s.stream.subs, sub)
+		sub.close()
+	}
+}
+
+// clickRecorded fans a recorded click out to the subscribers it matches.
+func (cs *clickStream) clickRecorded(_ context.Context, e event.ClickRecorded) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	// Live views follow the default stats and show human clicks only.
+	if len(cs.subs) == 0 || e.Click.Bot != "" {
+		return
+	}
+
+	live := e.Live()
+	for sub := range cs.subs {
+		if !sub.filter.Matches(&live) {
+			continue
+		}
+		select {
+		case sub.ch <- live:
+		default:
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// Device classes stored on clicks.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceTV      = "tv"
+	DeviceBot     = "bot"
+)
+
+// uaRule maps a User-Agent pattern to a family name. The first capture
+// group, if any, is the version.
+type uaRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+func rule(name, pattern string) uaRule {
+	return uaRule{name: name, pattern: regexp.MustCompile(pattern)}
+}
+
+// browserRules are checked in order. Browsers built on Chromium or WebKit
+// also send "Chrome/" and "Safari/", so the specific ones must come first.
+var browserRules = []uaRule{
+	rule("Facebook", `FBAV/(\d+)`),
+	rule("Instagram", `Instagram (\d+)`),
+	rule("Edge", `(?:Edg|Edge|EdgA|EdgiOS)/(\d+)`),
+	rule("Opera", `(?:OPR|OPT|Opera)/(\d+)`),
+	rule("Samsung Internet", `SamsungBrowser/(\d+)`),
+	rule("Yandex", `YaBrowser/(\d+)`),
+	rule("Vivaldi", `Vivaldi/(\d+)`),
+	rule("UC Browser", `UCBrowser/(\d+)`),
+	rule("Firefox", `(?:Firefox|FxiOS)/(\d+)`),
+	rule("Chrome", `(?:Chrome|CriOS)/(\d+)`),
+	rule("Safari", `Version/(\d+)[\d.]*(?: Mobile/\w+)? Safari/`),
+	rule("Internet Explorer", `MSIE (\d+)|Trident/.*rv:(\d+)`),
+	rule("curl", `^curl/(\d+)`),
+	rule("Wget", `^Wget/(\d+)`),
+}
+
+// osRules are checked in order.
+var osRules = []uaRule{
+	rule("iOS", `(?:iPhone|iPad|iPod).*?OS (\d+(?:_\d+)?)`),
+	rule("Android", `Android (\d+(?:\.\d+)?)`),
+	rule("Windows", `Windows NT (\d+\.\d+)`),
+	rule("Windows Phone", `Windows Phone (?:OS )?(\d+)`),
+	rule("ChromeOS", `CrOS \w+ (\d+)`),
+	rule("macOS", `Mac OS X (\d+(?:[_.]\d+)?)`),
+	rule("HarmonyOS", `HarmonyOS`),
+	rule("Linux", `Linux|X11`),
+}
+
+// windowsVersions maps NT kernel versions to marketing names. Windows 11
+// still reports NT 10.0.
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+var (
+	tvPattern     = regexp.MustCompile(`(?i)smart-?tv|googletv|appletv|hbbtv|crkey|roku|tizen.*tv|web0s|bravia|aftb|aftt|aftm`)
+	tabletPattern = regexp.MustCompile(`(?i)ipad|tablet|kindle|silk/|playbook|sm-t\d`)
+	mobilePattern = regexp.MustCompile(`(?i)mobi|iphone|ipod|android.*mobile|windows phone|blackberry|opera mini`)
+)
+
+// ParseUserAgent extracts browser family and major version, OS family and
+// version, and device class from a User-Agent header. Unrecognised parts are
+// left empty.
+func ParseUserAgent(userAgent string) domain.UserAgentInfo {
+	var info domain.UserAgentInfo
+	if strings.TrimSpace(userAgent) == "" {
+		return info
+	}
+
+	info.Browser, info.BrowserVersion = match(browserRules, userAgent)
+	info.OS, info.OSVersion = match(osRules, userAgent)
+
+	switch info.OS {
+	case "Windows":
+		if name, ok := windowsVersions[info.OSVersion]; ok {
+			info.OSVersion = name
+		}
+	case "iOS", "macOS":
+		info.OSVersion = strings.ReplaceAll(info.OSVersion, "_", ".")
+	}
+
+	// iPadOS 13+ requests desktop sites with a macOS User-Agent; there is no
+	// reliable way to tell them apart, so they count as macOS desktops.
+	info.Device = deviceClass(userAgent, info.OS)
+	return info
+}
+
+func match(rules []uaRule, userAgent string) (name, version string) {
+	for _, r := range rules {
+		m := r.pattern.FindStringSubmatch(userAgent)
+		if m == nil {
+			continue
+		}
+		for _, group := range m[1:] {
+			if group != "" {
+				return r.name, group
+			}
+		}
+		return r.name, ""
+	}
+	return "", ""
+}
+
+func deviceClass(userAgent, os string) string {
+	switch {
+	case IsBot(userAgent):
+		return DeviceBot
+	case tvPattern.MatchString(userAgent):
+		return DeviceTV
+	case tabletPattern.MatchString(userAgent):
+		return DeviceTablet
+	case os == "Android" && !strings.Contains(userAgent, "Mobile"):
+		// Android tablets omit "Mobile" from their User-Agent.
+		return DeviceTablet
+	case mobilePattern.MatchString(userAgent):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
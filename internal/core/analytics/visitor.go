@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/port"
+)
+
+// visitorSaltKey is the config key holding the current day's salt as
+// "<YYYY-MM-DD>:<hex>". Only one salt is ever stored, so once the day rolls
+// over the previous salt is gone and old visitor IDs can no longer be
+// recomputed from an IP address.
+const visitorSaltKey = "analytics.visitor_salt"
+
+// saltRotator hands out a random salt that changes at midnight UTC.
+type saltRotator struct {
+	store port.ConfigRepository
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+// get returns the salt for the day containing now. The salt is persisted so
+// restarts do not split a visitor in two; if the store is unavailable a
+// memory-only salt is used for the rest of the day.
+func (r *saltRotator) get(ctx context.Context, now time.Time) []byte {
+	day := now.UTC().Format("2006-01-02")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.day == day {
+		return r.salt
+	}
+
+	if r.store != nil {
+		if value, err := r.store.Get(ctx, visitorSaltKey); err == nil {
+			if storedDay, encoded, ok := strings.Cut(value, ":"); ok && storedDay == day {
+				if salt, err := hex.DecodeString(encoded); err == nil && len(salt) > 0 {
+					r.day, r.salt = day, salt
+					return salt
+				}
+			}
+		}
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		// crypto/rand does not fail on supported platforms.
+		panic(err)
+	}
+	if r.store != nil {
+		// Overwriting the previous day's salt is what makes it unrecoverable.
+		_ = r.store.Set(ctx, visitorSaltKey, day+":"+hex.EncodeToString(salt))
+	}
+
+	r.day, r.salt = day, salt
+	return salt
+}
+
+// visitorID derives an opaque per-link, per-day visitor identifier. The same
+// browser on the same address gets the same ID for a link until the salt
+// rotates; nothing in it can be reversed to the IP without the salt.
+func visitorID(salt []byte, linkID int64, ip, userAgent string) string {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(strconv.FormatInt(linkID, 10)))
+	h.Write([]byte{0})
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 // Click represents a single click/visit on a shortened link.
 type Click struct {
@@ -11,6 +14,148 @@ type Click struct {
 	DeviceHash string    `json:"device_hash,omitempty"`
 	UserAgent  string    `json:"-"`
 	IPHash     string    `json:"-"`
+	Country    string    `json:"country,omitempty"`
+	Region     string    `json:"region,omitempty"`
+	City       string    `json:"city,omitempty"`
+
+	// ReferrerHost is the referrer's host without "www.", empty for direct.
+	ReferrerHost string `json:"referrer_host,omitempty"`
+	Channel      string `json:"channel,omitempty"`
+
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browser_version,omitempty"`
+	OS             string `json:"os,omitempty"`
+	OSVersion      string `json:"os_version,omitempty"`
+	DeviceType     string `json:"device_type,omitempty"`
+
+	// VisitorID is a salted hash of IP, User-Agent and link that changes
+	// daily; it identifies unique visitors without storing the address.
+	VisitorID string `json:"-"`
+
+	// Token is appended to the destination URL when conversion tracking is
+	// on; the destination reports conversions for the click with it.
+	Token string `json:"-"`
+
+	// Bot is the class of the automated client behind the click, empty for
+	// people. Bot clicks are left out of stats unless asked for.
+	Bot string `json:"bot,omitempty"`
+
+	// Source tells how the short link was opened: scanned from a QR code,
+	// followed directly or resolved by a program. Empty for clicks recorded
+	// before sources were tracked.
+	Source string `json:"source,omitempty"`
+}
+
+// LiveClick is a recorded click as published to live stream subscribers.
+type LiveClick struct {
+	Click
+	Slug     string   `json:"slug"`
+	FolderID *int64   `json:"folder_id,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// ClickStreamFilter selects the live clicks a subscriber receives. Empty
+// fields match every click.
+type ClickStreamFilter struct {
+	Slug     string
+	FolderID *int64
+	Tag      string
+}
+
+// Matches reports whether c passes the filter.
+func (f ClickStreamFilter) Matches(c *LiveClick) bool {
+	if f.Slug != "" && c.Slug != f.Slug {
+		return false
+	}
+	if f.FolderID != nil && (c.FolderID == nil || *c.FolderID != *f.FolderID) {
+		return false
+	}
+	if f.Tag != "" {
+		for _, tag := range c.Tags {
+			if tag == f.Tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// UserAgentInfo is the parsed form of a User-Agent header.
+type UserAgentInfo struct {
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browser_version,omitempty"`
+	OS             string `json:"os,omitempty"`
+	OSVersion      string `json:"os_version,omitempty"`
+	// Device is one of desktop, mobile, tablet, tv or bot.
+	Device string `json:"device,omitempty"`
+}
+
+// GeoLocation is the coarse location of a client IP address.
+type GeoLocation struct {
+	// Country is the ISO 3166-1 alpha-2 code.
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// Referrer channels a click can be attributed to.
+const (
+	ChannelSearch    = "search"
+	ChannelSocial    = "social"
+	ChannelEmail     = "email"
+	ChannelMessaging = "messaging"
+	ChannelInternal  = "internal"
+	ChannelDirect    = "direct"
+	ChannelOther     = "other"
+)
+
+// ReferrerChannels lists every referrer channel.
+var ReferrerChannels = []string{
+	ChannelSearch, ChannelSocial, ChannelEmail, ChannelMessaging,
+	ChannelInternal, ChannelDirect, ChannelOther,
+}
+
+// IsReferrerChannel reports whether s is a known referrer channel.
+func IsReferrerChannel(s string) bool {
+	for _, c := range ReferrerChannels {
+		if c == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceParam is the query parameter marking where a short link was opened
+// from, e.g. "?src=qr" in generated QR codes. It is never forwarded.
+const SourceParam = "src"
+
+// Click sources.
+const (
+	SourceQR     = "qr"
+	SourceDirect = "direct"
+	SourceAPI    = "api"
+)
+
+// Bot classes an automated click can be attributed to.
+const (
+	BotSearch     = "search"
+	BotSocial     = "social"
+	BotMonitoring = "monitoring"
+	BotUnknown    = "unknown"
+)
+
+// BotClasses lists every bot class.
+var BotClasses = []string{BotSearch, BotSocial, BotMonitoring, BotUnknown}
+
+// IsBotClass reports whether s is a known bot class.
+func IsBotClass(s string) bool {
+	for _, c := range BotClasses {
+		if c == s {
+			return true
+		}
+	}
+	return false
 }
 
 // ClickStats contains aggregated click statistics for a link.
@@ -20,12 +165,135 @@ type ClickStats struct {
 	ClicksByMonth []MonthStats     `json:"clicks_by_month,omitempty"`
 	TopReferrers  []ReferrerStats  `json:"top_referrers,omitempty"`
 	DeviceStats   []DeviceStats    `json:"device_stats,omitempty"`
+	Browsers      []BrowserStats   `json:"browsers,omitempty"`
+	OSStats       []OSStats        `json:"operating_systems,omitempty"`
+	Channels      []ChannelStats   `json:"channels,omitempty"`
+	TopCountries  []CountryStats   `json:"top_countries,omitempty"`
+	TopCities     []CityStats      `json:"top_cities,omitempty"`
+
+	// UniqueVisitors counts distinct visitors over the filtered range.
+	// Visitor IDs rotate daily, so someone returning on another day is
+	// counted again.
+	UniqueVisitors int64 `json:"unique_visitors"`
+
+	// ClicksByHour is only filled in for ranges of up to HourlyStatsRange.
+	ClicksByHour []HourStats `json:"clicks_by_hour,omitempty"`
+
+	// Conversions counts conversions reported in the filtered range, and
+	// ConversionRate is them as a percentage of the clicks recorded in it.
+	Conversions    int64       `json:"conversions"`
+	ConversionRate float64     `json:"conversion_rate"`
+	Goals          []GoalStats `json:"goals,omitempty"`
+
+	// BotClicks counts the bot clicks in the filtered range, whether or not
+	// the other stats include them, and Bots breaks them down by class.
+	BotClicks int64      `json:"bot_clicks"`
+	Bots      []BotStats `json:"bots,omitempty"`
+
+	// Sources breaks clicks down by how the link was opened.
+	Sources []SourceStats `json:"sources,omitempty"`
+
+	// Comparison is set when stats were requested with a compare mode.
+	Comparison *StatsComparison `json:"comparison,omitempty"`
+}
+
+// StatsCompare selects the window stats are compared against.
+type StatsCompare string
+
+const (
+	// StatsComparePrevious compares with the window of the same length
+	// ending where the current one starts.
+	StatsComparePrevious StatsCompare = "previous"
+	// StatsCompareYear compares with the same window a year earlier.
+	StatsCompareYear StatsCompare = "year"
+)
+
+// IsValid reports whether c is a known compare mode.
+func (c StatsCompare) IsValid() bool {
+	return c == StatsComparePrevious || c == StatsCompareYear
+}
+
+// Window returns the window to compare [start, end] with.
+func (c StatsCompare) Window(start, end time.Time) (time.Time, time.Time) {
+	if c == StatsCompareYear {
+		return start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0)
+	}
+	// The previous window stops just short of start, which the current
+	// window already counts.
+	return start.Add(-end.Sub(start)), start.Add(-time.Nanosecond)
+}
+
+// StatsComparison holds the stats of the window compared against and the
+// change from it.
+type StatsComparison struct {
+	Mode      StatsCompare `json:"mode"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Previous  *ClickStats  `json:"previous"`
+
+	TotalClicks    MetricDelta   `json:"total_clicks"`
+	UniqueVisitors MetricDelta   `json:"unique_visitors"`
+	TopReferrers   []MetricDelta `json:"top_referrers"`
+	DeviceStats    []MetricDelta `json:"device_stats"`
+}
+
+// MetricDelta compares one count between two windows. Change is the
+// percentage difference, or nil when there was nothing to compare with.
+type MetricDelta struct {
+	Key      string   `json:"key"`
+	Current  int64    `json:"current"`
+	Previous int64    `json:"previous"`
+	Change   *float64 `json:"change"`
+}
+
+// NewMetricDelta returns the delta between current and previous, with the
+// change rounded to one decimal place.
+func NewMetricDelta(key string, current, previous int64) MetricDelta {
+	d := MetricDelta{Key: key, Current: current, Previous: previous}
+	if previous != 0 {
+		change := math.Round(float64(current-previous)/float64(previous)*1000) / 10
+		d.Change = &change
+	}
+	return d
+}
+
+// WorkspaceStats contains click statistics aggregated across links.
+type WorkspaceStats struct {
+	TotalClicks int64 `json:"total_clicks"`
+	// UniqueVisitors is summed per link: visitor IDs are link-specific, so
+	// someone who opens two links counts twice.
+	UniqueVisitors int64            `json:"unique_visitors"`
+	ActiveLinks    int64            `json:"active_links"`
+	ClicksByDay    []DayStats       `json:"clicks_by_day,omitempty"`
+	ClicksByHour   []HourStats      `json:"clicks_by_hour,omitempty"`
+	TopLinks       []LinkClickStats `json:"top_links,omitempty"`
+	TopReferrers   []ReferrerStats  `json:"top_referrers,omitempty"`
+	Channels       []ChannelStats   `json:"channels,omitempty"`
+	TopCountries   []CountryStats   `json:"top_countries,omitempty"`
+	DeviceStats    []DeviceStats    `json:"device_stats,omitempty"`
+}
+
+// LinkClickStats contains click counts for one link in workspace stats.
+type LinkClickStats struct {
+	LinkID   int64  `json:"link_id"`
+	Slug     string `json:"slug"`
+	Clicks   int64  `json:"clicks"`
+	Visitors int64  `json:"visitors"`
+}
+
+// HourStats contains click counts for one hour, labelled "YYYY-MM-DD HH:00"
+// in the stats time zone.
+type HourStats struct {
+	Hour     string `json:"hour"`
+	Clicks   int64  `json:"clicks"`
+	Visitors int64  `json:"visitors"`
 }
 
 // DayStats contains click counts for a specific day.
 type DayStats struct {
-	Date   string `json:"date"`
-	Clicks int64  `json:"clicks"`
+	Date     string `json:"date"`
+	Clicks   int64  `json:"clicks"`
+	Visitors int64  `json:"visitors"`
 }
 
 // MonthStats contains click counts for a specific month.
@@ -46,6 +314,57 @@ type DeviceStats struct {
 	Clicks     int64  `json:"clicks"`
 }
 
+// ChannelStats contains click counts for a referrer channel and its top hosts.
+type ChannelStats struct {
+	Channel string          `json:"channel"`
+	Clicks  int64           `json:"clicks"`
+	Hosts   []ReferrerStats `json:"hosts,omitempty"`
+}
+
+// SourceStats contains click counts by click source.
+type SourceStats struct {
+	Source string `json:"source"`
+	Clicks int64  `json:"clicks"`
+}
+
+// BotStats contains click counts by bot class.
+type BotStats struct {
+	Class  string `json:"class"`
+	Clicks int64  `json:"clicks"`
+}
+
+// BrowserStats contains click counts by browser family.
+type BrowserStats struct {
+	Browser string `json:"browser"`
+	Clicks  int64  `json:"clicks"`
+}
+
+// OSStats contains click counts by operating system family.
+type OSStats struct {
+	OS     string `json:"os"`
+	Clicks int64  `json:"clicks"`
+}
+
+// CountryStats contains click counts from a specific country.
+type CountryStats struct {
+	Country string `json:"country"`
+	Clicks  int64  `json:"clicks"`
+}
+
+// CityStats contains click counts from a specific city.
+type CityStats struct {
+	City    string `json:"city"`
+	Region  string `json:"region,omitempty"`
+	Country string `json:"country"`
+	Clicks  int64  `json:"clicks"`
+}
+
+// GeoStats contains location breakdowns for a link.
+type GeoStats struct {
+	Countries []CountryStats `json:"countries"`
+	Cities    []CityStats    `json:"cities"`
+}
+
 // StatsPeriod defines the time range for statistics queries.
 type StatsPeriod string
 
@@ -57,9 +376,93 @@ const (
 	StatsPeriodAll   StatsPeriod = "all"
 )
 
+// IsValid reports whether p is a known period.
+func (p StatsPeriod) IsValid() bool {
+	switch p {
+	case StatsPeriodDay, StatsPeriodWeek, StatsPeriodMonth, StatsPeriodYear, StatsPeriodAll:
+		return true
+	}
+	return false
+}
+
+// Since returns the start of the period ending at now, or nil for all time.
+func (p StatsPeriod) Since(now time.Time) *time.Time {
+	var start time.Time
+	switch p {
+	case StatsPeriodDay:
+		start = now.AddDate(0, 0, -1)
+	case StatsPeriodWeek:
+		start = now.AddDate(0, 0, -7)
+	case StatsPeriodMonth:
+		start = now.AddDate(0, -1, 0)
+	case StatsPeriodYear:
+		start = now.AddDate(-1, 0, 0)
+	default:
+		return nil
+	}
+	return &start
+}
+
+// BotTraffic selects how stats treat bot clicks.
+type BotTraffic string
+
+const (
+	// BotsExclude leaves bot clicks out; it is the default.
+	BotsExclude BotTraffic = ""
+	// BotsInclude counts bot clicks along with human ones.
+	BotsInclude BotTraffic = "include"
+	// BotsOnly counts nothing but bot clicks.
+	BotsOnly BotTraffic = "only"
+)
+
+// IsValid reports whether b is a known bot traffic mode.
+func (b BotTraffic) IsValid() bool {
+	return b == BotsExclude || b == BotsInclude || b == BotsOnly
+}
+
 // StatsFilter contains filter options for retrieving statistics.
 type StatsFilter struct {
 	Period    StatsPeriod `json:"period,omitempty"`
 	StartDate *time.Time  `json:"start_date,omitempty"`
 	EndDate   *time.Time  `json:"end_date,omitempty"`
+	// Channel restricts stats to clicks from one referrer channel.
+	Channel string `json:"channel,omitempty"`
+	// FolderID and Tag scope workspace stats to links in a folder or with a tag.
+	FolderID *int64 `json:"folder_id,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	// Bots selects whether bot clicks are counted.
+	Bots BotTraffic `json:"bots,omitempty"`
+	// Location is the time zone clicks are grouped into hours, days and
+	// months in; nil means UTC.
+	Location *time.Location `json:"-"`
+}
+
+// HourlyStatsRange is the longest range for which stats include clicks by
+// hour.
+const HourlyStatsRange = 48 * time.Hour
+
+// HasRange reports whether the filter limits clicks to a time range.
+func (f StatsFilter) HasRange() bool {
+	return f.StartDate != nil || f.EndDate != nil
+}
+
+// IsShort reports whether the filter's range, ending now if open-ended, is
+// short enough for clicks by hour.
+func (f StatsFilter) IsShort(now time.Time) bool {
+	if f.StartDate == nil {
+		return false
+	}
+	end := now
+	if f.EndDate != nil && f.EndDate.Before(end) {
+		end = *f.EndDate
+	}
+	return end.Sub(*f.StartDate) <= HourlyStatsRange
+}
+
+// Loc returns the time zone to group clicks in.
+func (f StatsFilter) Loc() *time.Location {
+	if f.Location == nil {
+		return time.UTC
+	}
+	return f.Location
 }
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// ConversionTokenParam is the query parameter carrying a click's token on
+// the destination URL.
+const ConversionTokenParam = "trelay_click"
+
+// DefaultConversionGoal is the goal of conversions reported without one.
+const DefaultConversionGoal = "conversion"
+
+// Conversion is a goal, such as a signup, reached by a visitor after
+// clicking a link. The destination reports it with the click's token.
+type Conversion struct {
+	ID        int64     `json:"id"`
+	LinkID    int64     `json:"link_id"`
+	ClickID   int64     `json:"click_id"`
+	Goal      string    `json:"goal"`
+	Channel   string    `json:"channel,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// GoalStats counts conversions for one goal. Rate is the percentage of
+// clicks that converted.
+type GoalStats struct {
+	Goal        string  `json:"goal"`
+	Conversions int64   `json:"conversions"`
+	Rate        float64 `json:"rate"`
+}
+
+// ConversionRate returns conversions as a percentage of clicks, rounded to
+// one decimal place.
+func ConversionRate(conversions, clicks int64) float64 {
+	if clicks == 0 {
+		return 0
+	}
+	return math.Round(float64(conversions)/float64(clicks)*1000) / 10
+}
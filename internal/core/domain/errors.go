@@ -31,6 +31,15 @@ var (
 	ErrFolderNotFound       = errors.New("folder not found")
 	ErrParentFolderNotFound = errors.New("parent folder not found")
 
+	// Report errors
+	ErrReportNotFound = errors.New("report not found")
+
+	// Webhook errors
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// Analytics errors
+	ErrClickNotFound = errors.New("click not found")
+
 	// Storage errors
 	ErrDatabase       = errors.New("database error")
 	ErrNotImplemented = errors.New("not implemented")
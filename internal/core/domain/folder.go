@@ -7,9 +7,22 @@ type Folder struct {
 	Name      string    `json:"name"`
 	ParentID  *int64    `json:"parent_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// Privacy is the default analytics privacy of links created in the
+	// folder.
+	Privacy AnalyticsPrivacy `json:"privacy"`
 }
 
 type CreateFolderRequest struct {
 	Name     string `json:"name"`
 	ParentID *int64 `json:"parent_id,omitempty"`
+	// Privacy defaults to the parent folder's settings when omitted.
+	Privacy *AnalyticsPrivacy `json:"privacy,omitempty"`
+}
+
+// UpdateFolderRequest renames a folder or changes its privacy defaults.
+// Changed defaults only apply to links created afterwards.
+type UpdateFolderRequest struct {
+	Name    *string           `json:"name,omitempty"`
+	Privacy *AnalyticsPrivacy `json:"privacy,omitempty"`
 }
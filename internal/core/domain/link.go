@@ -7,23 +7,29 @@ import (
 
 // Link represents a shortened URL with its metadata.
 type Link struct {
-	ID           int64      `json:"id"`
-	Slug         string     `json:"slug"`
-	OriginalURL  string     `json:"original_url"`
-	Domain       string     `json:"domain,omitempty"`
-	PasswordHash string     `json:"-"`
-	HasPassword  bool       `json:"has_password"`
-	IsOneTime    bool       `json:"is_one_time,omitempty"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
-	Tags         []string   `json:"tags,omitempty"`
-	FolderID     *int64     `json:"folder_id,omitempty"`
-	OGTitle       string `json:"og_title,omitempty"`
-	OGDescription string `json:"og_description,omitempty"`
-	OGImageURL    string `json:"og_image_url,omitempty"`
-	ClickCount   int64      `json:"click_count"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
-	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	ID             int64        `json:"id"`
+	Slug           string       `json:"slug"`
+	OriginalURL    string       `json:"original_url"`
+	Domain         string       `json:"domain,omitempty"`
+	PasswordHash   string       `json:"-"`
+	HasPassword    bool         `json:"has_password"`
+	IsOneTime      bool         `json:"is_one_time,omitempty"`
+	ExpiresAt      *time.Time   `json:"expires_at,omitempty"`
+	Tags           []string     `json:"tags,omitempty"`
+	FolderID       *int64       `json:"folder_id,omitempty"`
+	OGTitle        string       `json:"og_title,omitempty"`
+	OGDescription  string       `json:"og_description,omitempty"`
+	OGImageURL     string       `json:"og_image_url,omitempty"`
+	SafetyOverride bool         `json:"safety_override,omitempty"`
+	Preview        *LinkPreview `json:"preview,omitempty"`
+	Health         *LinkHealth  `json:"health,omitempty"`
+	ClickCount     int64        `json:"click_count"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	DeletedAt      *time.Time   `json:"deleted_at,omitempty"`
+
+	// Privacy limits what analytics records about the link's clicks.
+	Privacy AnalyticsPrivacy `json:"privacy"`
 }
 
 // IsExpired checks if the link has expired.
@@ -60,12 +66,120 @@ func (l *Link) ParseTagsJSON(data string) error {
 	return json.Unmarshal([]byte(data), &l.Tags)
 }
 
-// LinkPreview contains Open Graph metadata for a link.
+// PreviewJSON returns the fetched preview as JSON for database storage.
+func (l *Link) PreviewJSON() (string, error) {
+	if l.Preview == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(l.Preview)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ParsePreviewJSON parses a stored preview from database.
+func (l *Link) ParsePreviewJSON(data string) error {
+	if data == "" || data == "null" {
+		l.Preview = nil
+		return nil
+	}
+	l.Preview = &LinkPreview{}
+	return json.Unmarshal([]byte(data), l.Preview)
+}
+
+// AnalyticsPrivacy limits what analytics records about a link's clicks.
+// Folders hold defaults that links created in them inherit.
+type AnalyticsPrivacy struct {
+	// DisableTracking records no clicks at all. The link's click counter
+	// still counts redirects.
+	DisableTracking bool `json:"disable_tracking,omitempty"`
+	// DropReferrer records clicks without their referrer or channel.
+	DropReferrer bool `json:"drop_referrer,omitempty"`
+	// DropUserAgent records clicks without browser, OS or device, and
+	// identifies visitors without their User-Agent.
+	DropUserAgent bool `json:"drop_user_agent,omitempty"`
+	// HonorDNT records no clicks sent with DNT: 1 or Sec-GPC: 1.
+	HonorDNT bool `json:"honor_dnt,omitempty"`
+}
+
+// JSON returns the settings as JSON for database storage.
+func (p AnalyticsPrivacy) JSON() (string, error) {
+	if p == (AnalyticsPrivacy{}) {
+		return "", nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ParseJSON parses settings stored by JSON.
+func (p *AnalyticsPrivacy) ParseJSON(data string) error {
+	*p = AnalyticsPrivacy{}
+	if data == "" || data == "null" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), p)
+}
+
+// LinkPreview contains page metadata (Open Graph, Twitter Card, JSON-LD,
+// oEmbed) fetched from a link's destination.
 type LinkPreview struct {
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	ImageURL    string `json:"image_url,omitempty"`
-	FetchedAt   time.Time `json:"fetched_at"`
+	Title        string    `json:"title,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	ImageURL     string    `json:"image_url,omitempty"`
+	SiteName     string    `json:"site_name,omitempty"`
+	Type         string    `json:"type,omitempty"`
+	CanonicalURL string    `json:"canonical_url,omitempty"`
+	FaviconURL   string    `json:"favicon_url,omitempty"`
+	OEmbedURL    string    `json:"oembed_url,omitempty"`
+	TwitterCard  string    `json:"twitter_card,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// LinkHealth records the outcome of the most recent destination check.
+type LinkHealth struct {
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	// StatusCode is the final HTTP status after redirects; 0 if the request failed.
+	StatusCode int `json:"status_code,omitempty"`
+	// RedirectURL is where the destination ended up when it redirected.
+	RedirectURL string `json:"redirect_url,omitempty"`
+	// FailureStreak counts consecutive failed checks; 0 means healthy.
+	FailureStreak int    `json:"failure_streak"`
+	Error         string `json:"error,omitempty"`
+}
+
+// IsBroken reports whether the most recent check failed.
+func (h *LinkHealth) IsBroken() bool {
+	return h != nil && h.FailureStreak > 0
+}
+
+// LinkCheckResult reports the outcome of checking a single link.
+type LinkCheckResult struct {
+	Slug        string `json:"slug"`
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+	Broken      bool   `json:"broken"`
+	Error       string `json:"error,omitempty"`
+}
+
+// MaxLinkChecks caps the links probed by one on-demand check, so a request
+// full of unreachable destinations still finishes within the server's
+// write timeout.
+const MaxLinkChecks = 20
+
+// CheckLinksRequest selects links to check on demand. With no slugs, live
+// links matching the folder and tags (or every live link) are checked a
+// page of Limit at a time, starting at Offset.
+type CheckLinksRequest struct {
+	Slugs    []string `json:"slugs,omitempty"`
+	FolderID *int64   `json:"folder_id,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+	Offset   int      `json:"offset,omitempty"`
 }
 
 // CreateLinkRequest contains data for creating a new link.
@@ -81,18 +195,27 @@ type CreateLinkRequest struct {
 	OGTitle       string   `json:"og_title,omitempty"`
 	OGDescription string   `json:"og_description,omitempty"`
 	OGImageURL    string   `json:"og_image_url,omitempty"`
+	// SafetyOverride skips destination screening (blocklists, address and
+	// homograph checks) for this link. Any authenticated caller may set it:
+	// the API key, and JWTs issued for it, are the admin credential.
+	SafetyOverride bool `json:"safety_override,omitempty"`
+	// Privacy defaults to the folder's settings when omitted.
+	Privacy *AnalyticsPrivacy `json:"privacy,omitempty"`
 }
 
 // UpdateLinkRequest contains data for updating an existing link.
 type UpdateLinkRequest struct {
-	URL             *string   `json:"url,omitempty"`
-	Password        *string   `json:"password,omitempty"`
-	TTLHours        *int      `json:"ttl_hours,omitempty"`
-	Tags            *[]string `json:"tags,omitempty"`
-	FolderID        *int64    `json:"folder_id,omitempty"`
-	OGTitle         *string   `json:"og_title,omitempty"`
-	OGDescription   *string   `json:"og_description,omitempty"`
-	OGImageURL      *string   `json:"og_image_url,omitempty"`
+	URL            *string   `json:"url,omitempty"`
+	Password       *string   `json:"password,omitempty"`
+	TTLHours       *int      `json:"ttl_hours,omitempty"`
+	Tags           *[]string `json:"tags,omitempty"`
+	FolderID       *int64    `json:"folder_id,omitempty"`
+	OGTitle        *string   `json:"og_title,omitempty"`
+	OGDescription  *string   `json:"og_description,omitempty"`
+	OGImageURL     *string   `json:"og_image_url,omitempty"`
+	SafetyOverride *bool     `json:"safety_override,omitempty"`
+
+	Privacy *AnalyticsPrivacy `json:"privacy,omitempty"`
 }
 
 // BulkUpdateLinksRequest updates multiple links from the dashboard.
@@ -120,9 +243,10 @@ type ListLinksFilter struct {
 	Offset         int      `json:"offset,omitempty"`
 	IncludeDeleted bool     `json:"include_deleted,omitempty"`
 	OnlyDeleted    bool     `json:"only_deleted,omitempty"`
-	CreatedAfter   string `json:"created_after,omitempty"`
-	CreatedBefore  string `json:"created_before,omitempty"`
-	ExpiresAfter   string `json:"expires_after,omitempty"`
-	ExpiresBefore  string `json:"expires_before,omitempty"`
-	HasExpiry      *bool  `json:"has_expiry,omitempty"`
+	CreatedAfter   string   `json:"created_after,omitempty"`
+	CreatedBefore  string   `json:"created_before,omitempty"`
+	ExpiresAfter   string   `json:"expires_after,omitempty"`
+	ExpiresBefore  string   `json:"expires_before,omitempty"`
+	HasExpiry      *bool    `json:"has_expiry,omitempty"`
+	Broken         *bool    `json:"broken,omitempty"`
 }
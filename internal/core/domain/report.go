@@ -0,0 +1,268 @@
+package domain
+
+import "time"
+
+// ReportScope selects which links a report summarises.
+type ReportScope string
+
+const (
+	ReportScopeLink      ReportScope = "link"
+	ReportScopeFolder    ReportScope = "folder"
+	ReportScopeTag       ReportScope = "tag"
+	ReportScopeWorkspace ReportScope = "workspace"
+)
+
+// IsValid reports whether s is a known report scope.
+func (s ReportScope) IsValid() bool {
+	switch s {
+	case ReportScopeLink, ReportScopeFolder, ReportScopeTag, ReportScopeWorkspace:
+		return true
+	}
+	return false
+}
+
+// ReportCadence is how often a report is sent. Each run covers the
+// previous whole day, week (Monday to Sunday) or month in the report's
+// time zone.
+type ReportCadence string
+
+const (
+	ReportDaily   ReportCadence = "daily"
+	ReportWeekly  ReportCadence = "weekly"
+	ReportMonthly ReportCadence = "monthly"
+)
+
+// IsValid reports whether c is a known cadence.
+func (c ReportCadence) IsValid() bool {
+	return c == ReportDaily || c == ReportWeekly || c == ReportMonthly
+}
+
+// PeriodStart returns the start of the period containing t, in loc.
+func (c ReportCadence) PeriodStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch c {
+	case ReportWeekly:
+		// Weeks start on Monday.
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case ReportMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return day
+	}
+}
+
+// Step returns the start of the period n periods after the one starting
+// at start; n may be negative.
+func (c ReportCadence) Step(start time.Time, n int) time.Time {
+	switch c {
+	case ReportWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case ReportMonthly:
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// LastPeriod returns the most recent whole period that ended by now.
+func (c ReportCadence) LastPeriod(now time.Time, loc *time.Location) (start, end time.Time) {
+	end = c.PeriodStart(now, loc)
+	return c.Step(end, -1), end
+}
+
+// ReportFormat is the document format a report is rendered in.
+type ReportFormat string
+
+const (
+	ReportJSON     ReportFormat = "json"
+	ReportCSV      ReportFormat = "csv"
+	ReportMarkdown ReportFormat = "markdown"
+)
+
+// IsValid reports whether f is a known report format.
+func (f ReportFormat) IsValid() bool {
+	return f == ReportJSON || f == ReportCSV || f == ReportMarkdown
+}
+
+// ContentType returns the MIME type of documents in format f.
+func (f ReportFormat) ContentType() string {
+	switch f {
+	case ReportCSV:
+		return "text/csv"
+	case ReportMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the file name extension of documents in format f.
+func (f ReportFormat) Extension() string {
+	if f == ReportMarkdown {
+		return "md"
+	}
+	return string(f)
+}
+
+// ReportDelivery is where a rendered report is sent.
+type ReportDelivery string
+
+const (
+	// ReportDeliveryWebhook POSTs the document to the report's webhook URL.
+	ReportDeliveryWebhook ReportDelivery = "webhook"
+	// ReportDeliveryFile writes the document into the server's report
+	// directory.
+	ReportDeliveryFile ReportDelivery = "file"
+)
+
+// Report metrics that can be included in a report.
+const (
+	ReportMetricClicks    = "clicks"
+	ReportMetricVisitors  = "visitors"
+	ReportMetricDaily     = "daily"
+	ReportMetricTopLinks  = "top_links"
+	ReportMetricReferrers = "referrers"
+	ReportMetricChannels  = "channels"
+	ReportMetricCountries = "countries"
+	ReportMetricDevices   = "devices"
+)
+
+// ReportMetrics lists every report metric, in the order they are rendered.
+// Reports without a metric list include them all.
+var ReportMetrics = []string{
+	ReportMetricClicks,
+	ReportMetricVisitors,
+	ReportMetricDaily,
+	ReportMetricTopLinks,
+	ReportMetricReferrers,
+	ReportMetricChannels,
+	ReportMetricCountries,
+	ReportMetricDevices,
+}
+
+// IsReportMetric reports whether name is a known report metric.
+func IsReportMetric(name string) bool {
+	for _, m := range ReportMetrics {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Report is a stats summary rendered and delivered on a schedule.
+type Report struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+
+	// Scope selects the links summarised: one link by Slug, the links in
+	// FolderID, the links with Tag, or every link.
+	Scope    ReportScope `json:"scope"`
+	Slug     string      `json:"slug,omitempty"`
+	FolderID *int64      `json:"folder_id,omitempty"`
+	Tag      string      `json:"tag,omitempty"`
+
+	Cadence ReportCadence `json:"cadence"`
+	// Timezone is the IANA zone periods start and end in.
+	Timezone string       `json:"timezone"`
+	Metrics  []string     `json:"metrics,omitempty"`
+	Format   ReportFormat `json:"format"`
+
+	Delivery   ReportDelivery `json:"delivery"`
+	WebhookURL string         `json:"webhook_url,omitempty"`
+	// Directory is a subdirectory of the server's report directory for
+	// file delivery; empty writes into the report directory itself.
+	Directory string `json:"directory,omitempty"`
+
+	Enabled   bool       `json:"enabled"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	// LastError is why the last run failed, or empty if it succeeded.
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HasMetric reports whether the report includes the named metric.
+func (r *Report) HasMetric(name string) bool {
+	if len(r.Metrics) == 0 {
+		return true
+	}
+	for _, m := range r.Metrics {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Location returns the report's time zone, falling back to UTC.
+func (r *Report) Location() *time.Location {
+	if loc, err := time.LoadLocation(r.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// CreateReportRequest defines a new report. Timezone defaults to UTC,
+// Format to markdown and Metrics to all of them.
+type CreateReportRequest struct {
+	Name       string         `json:"name"`
+	Scope      ReportScope    `json:"scope"`
+	Slug       string         `json:"slug,omitempty"`
+	FolderID   *int64         `json:"folder_id,omitempty"`
+	Tag        string         `json:"tag,omitempty"`
+	Cadence    ReportCadence  `json:"cadence"`
+	Timezone   string         `json:"timezone,omitempty"`
+	Metrics    []string       `json:"metrics,omitempty"`
+	Format     ReportFormat   `json:"format,omitempty"`
+	Delivery   ReportDelivery `json:"delivery"`
+	WebhookURL string         `json:"webhook_url,omitempty"`
+	Directory  string         `json:"directory,omitempty"`
+	Enabled    *bool          `json:"enabled,omitempty"`
+}
+
+// UpdateReportRequest changes a report's schedule, contents or delivery.
+// The scope of a report cannot be changed.
+type UpdateReportRequest struct {
+	Name       *string         `json:"name,omitempty"`
+	Cadence    *ReportCadence  `json:"cadence,omitempty"`
+	Timezone   *string         `json:"timezone,omitempty"`
+	Metrics    []string        `json:"metrics,omitempty"`
+	Format     *ReportFormat   `json:"format,omitempty"`
+	Delivery   *ReportDelivery `json:"delivery,omitempty"`
+	WebhookURL *string         `json:"webhook_url,omitempty"`
+	Directory  *string         `json:"directory,omitempty"`
+	Enabled    *bool           `json:"enabled,omitempty"`
+}
+
+// ReportData is the content of one report run.
+type ReportData struct {
+	Report string      `json:"report"`
+	Scope  ReportScope `json:"scope"`
+	// Target names the summarised link, folder or tag.
+	Target      string    `json:"target,omitempty"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	TotalClicks    *int64           `json:"total_clicks,omitempty"`
+	UniqueVisitors *int64           `json:"unique_visitors,omitempty"`
+	ClicksByDay    []DayStats       `json:"clicks_by_day,omitempty"`
+	TopLinks       []LinkClickStats `json:"top_links,omitempty"`
+	TopReferrers   []ReferrerStats  `json:"top_referrers,omitempty"`
+	Channels       []ChannelStats   `json:"channels,omitempty"`
+	TopCountries   []CountryStats   `json:"top_countries,omitempty"`
+	DeviceStats    []DeviceStats    `json:"device_stats,omitempty"`
+}
+
+// ReportRun is the outcome of rendering and delivering a report.
+type ReportRun struct {
+	ReportID int64     `json:"report_id"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	RanAt    time.Time `json:"ran_at"`
+	// Location is the file written or the webhook URL posted to.
+	Location string `json:"location,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEvent names something that happened which webhooks can subscribe
+// to.
+type WebhookEvent string
+
+const (
+	EventLinkCreated   WebhookEvent = "link.created"
+	EventLinkUpdated   WebhookEvent = "link.updated"
+	EventLinkDeleted   WebhookEvent = "link.deleted"
+	EventLinkExpired   WebhookEvent = "link.expired"
+	EventLinkBurned    WebhookEvent = "link.burned"
+	EventClickRecorded WebhookEvent = "click.recorded"
+
+	// EventWebhookTest is sent only by the test endpoint, to the webhook
+	// being tested.
+	EventWebhookTest WebhookEvent = "webhook.test"
+)
+
+// WebhookEvents lists every event webhooks can subscribe to.
+var WebhookEvents = []WebhookEvent{
+	EventLinkCreated,
+	EventLinkUpdated,
+	EventLinkDeleted,
+	EventLinkExpired,
+	EventLinkBurned,
+	EventClickRecorded,
+}
+
+// IsValid reports whether e is an event webhooks can subscribe to.
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a subscription that POSTs signed event payloads to a URL.
+type Webhook struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+	// Secret keys the HMAC-SHA256 signature of every payload. It is only
+	// returned when it is set, on create or update.
+	Secret string `json:"secret,omitempty"`
+	// Events the webhook receives; empty means all of them.
+	Events    []WebhookEvent `json:"events,omitempty"`
+	Enabled   bool           `json:"enabled"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook receives event.
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookRequest defines a new webhook. A secret is generated when
+// none is given.
+type CreateWebhookRequest struct {
+	URL     string         `json:"url"`
+	Secret  string         `json:"secret,omitempty"`
+	Events  []WebhookEvent `json:"events,omitempty"`
+	Enabled *bool          `json:"enabled,omitempty"`
+}
+
+// UpdateWebhookRequest changes a webhook. Setting RotateSecret replaces the
+// secret with a generated one.
+type UpdateWebhookRequest struct {
+	URL          *string        `json:"url,omitempty"`
+	Secret       *string        `json:"secret,omitempty"`
+	RotateSecret bool           `json:"rotate_secret,omitempty"`
+	Events       []WebhookEvent `json:"events,omitempty"`
+	Enabled      *bool          `json:"enabled,omitempty"`
+}
+
+// WebhookPayload is the JSON body of every webhook request.
+type WebhookPayload struct {
+	Event     WebhookEvent    `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// DeliveryStatus is where a webhook delivery is in its lifecycle.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered deliveries got a 2xx response.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed deliveries ran out of attempts.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event queued for, or sent to, one webhook.
+type WebhookDelivery struct {
+	ID        int64          `json:"id"`
+	WebhookID int64          `json:"webhook_id"`
+	Event     WebhookEvent   `json:"event"`
+	Payload   string         `json:"payload"`
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	// NextAttemptAt is when a pending delivery is tried next.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// ResponseStatus is the HTTP status of the last attempt, or 0 if no
+	// response was received.
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// WebhookDeliveryFilter selects deliveries from a webhook's log.
+type WebhookDeliveryFilter struct {
+	Status DeliveryStatus
+	Limit  int
+}
@@ -0,0 +1,66 @@
+// Package event carries domain events from the services that cause them to
+// the features that react to them, such as webhooks and live click
+// streams, so services need not know who is listening.
+package event
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// Bus delivers published events to the handlers subscribed to their type.
+// Handlers run synchronously, in subscription order, on the publisher's
+// goroutine: they must return quickly and hand slow work off elsewhere.
+// A nil *Bus accepts publishes and drops them.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]func(context.Context, any)
+}
+
+// NewBus creates a bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[reflect.Type][]func(context.Context, any))}
+}
+
+// Subscribe calls fn with every event of type E published on b.
+func Subscribe[E any](b *Bus, fn func(ctx context.Context, e E)) {
+	t := typeOf[E]()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[t] = append(b.handlers[t], func(ctx context.Context, e any) {
+		fn(ctx, e.(E))
+	})
+}
+
+// Publish calls every handler subscribed to events of type E with e.
+func Publish[E any](ctx context.Context, b *Bus, e E) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[typeOf[E]()]
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
+
+// Subscribed reports whether any handler is subscribed to events of type
+// E, so publishers can skip work nobody would see.
+func Subscribed[E any](b *Bus) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.handlers[typeOf[E]()]) > 0
+}
+
+func typeOf[E any]() reflect.Type {
+	return reflect.TypeOf((*E)(nil)).Elem()
+}
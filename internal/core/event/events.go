@@ -0,0 +1,67 @@
+package event
+
+import "github.com/aftaab/trelay/internal/core/domain"
+
+// LinkCreated is published after a link is stored.
+type LinkCreated struct {
+	Link *domain.Link
+}
+
+// LinkUpdated is published after a link is changed, alone or in a bulk
+// update.
+type LinkUpdated struct {
+	Link *domain.Link
+}
+
+// LinkDeleted is published after a link is moved to the trash, or removed
+// for good when Permanent is set. Removing a link that was already in the
+// trash sets Trashed as well.
+type LinkDeleted struct {
+	Link      *domain.Link
+	Permanent bool
+	Trashed   bool
+}
+
+// LinkRestored is published after a link is taken out of the trash.
+type LinkRestored struct {
+	Link *domain.Link
+}
+
+// LinkBurned is published after a one-time link is used up.
+type LinkBurned struct {
+	Link *domain.Link
+}
+
+// LinkExpired is published shortly after a live link's expiry time passes.
+type LinkExpired struct {
+	Link *domain.Link
+}
+
+// ClickRecorded is published after a click is stored, bots included.
+type ClickRecorded struct {
+	Link  *domain.Link
+	Click *domain.Click
+}
+
+// Live returns the click as shown to live stream subscribers.
+func (e ClickRecorded) Live() domain.LiveClick {
+	return domain.LiveClick{
+		Click:    *e.Click,
+		Slug:     e.Link.Slug,
+		FolderID: e.Link.FolderID,
+		Tags:     e.Link.Tags,
+	}
+}
+
+// ClicksRecorded is published once per stored batch of clicks, after the
+// ClickRecorded event of each click in it, for subscribers that write
+// something per click and want to do it in one go.
+type ClicksRecorded struct {
+	Clicks []ClickRecorded
+}
+
+// FolderDeleted is published after a folder is deleted. Its links keep
+// their folder ID.
+type FolderDeleted struct {
+	Folder *domain.Folder
+}
@@ -0,0 +1,62 @@
+package filewatch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Watch polls the given files every interval and calls onChange whenever any
+// of them is created, removed, or has its size or modification time changed.
+// It blocks until ctx is cancelled.
+func Watch(ctx context.Context, paths []string, interval time.Duration, onChange func()) {
+	if len(paths) == 0 || interval <= 0 {
+		return
+	}
+
+	last := snapshot(paths)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := snapshot(paths)
+			if changed(last, current) {
+				last = current
+				onChange()
+			}
+		}
+	}
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func snapshot(paths []string) map[string]fileState {
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			states[path] = fileState{}
+			continue
+		}
+		states[path] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return states
+}
+
+func changed(a, b map[string]fileState) bool {
+	for path, state := range b {
+		prev, ok := a[path]
+		if !ok || prev.exists != state.exists || prev.size != state.size || !prev.modTime.Equal(state.modTime) {
+			return true
+		}
+	}
+	return false
+}
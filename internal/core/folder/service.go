@@ -5,42 +5,78 @@ import (
 	"time"
 
 	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/event"
 	"github.com/aftaab/trelay/internal/core/port"
 )
 
 type Service struct {
-	repo port.FolderRepository
+	repo   port.FolderRepository
+	events *event.Bus
 }
 
 func NewService(repo port.FolderRepository) *Service {
 	return &Service{repo: repo}
 }
 
+// SetEventBus publishes folder deletions on bus.
+func (s *Service) SetEventBus(bus *event.Bus) {
+	s.events = bus
+}
+
 func (s *Service) Create(ctx context.Context, req domain.CreateFolderRequest) (*domain.Folder, error) {
 	if req.Name == "" {
 		return nil, domain.NewValidationError("name", "folder name is required")
 	}
 
+	folder := &domain.Folder{
+		Name:      req.Name,
+		ParentID:  req.ParentID,
+		CreatedAt: time.Now(),
+	}
+
 	// Validate parent folder exists if provided
 	if req.ParentID != nil {
-		_, err := s.repo.GetByID(ctx, *req.ParentID)
+		parent, err := s.repo.GetByID(ctx, *req.ParentID)
 		if err != nil {
 			if err == domain.ErrFolderNotFound {
 				return nil, domain.ErrParentFolderNotFound
 			}
 			return nil, err
 		}
+		folder.Privacy = parent.Privacy
 	}
 
-	folder := &domain.Folder{
-		Name:      req.Name,
-		ParentID:  req.ParentID,
-		CreatedAt: time.Now(),
+	if req.Privacy != nil {
+		folder.Privacy = *req.Privacy
 	}
 
 	return s.repo.Create(ctx, folder)
 }
 
+// Update renames a folder or changes the privacy defaults of links created
+// in it from now on.
+func (s *Service) Update(ctx context.Context, id int64, req domain.UpdateFolderRequest) (*domain.Folder, error) {
+	folder, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, domain.NewValidationError("name", "folder name is required")
+		}
+		folder.Name = *req.Name
+	}
+	if req.Privacy != nil {
+		folder.Privacy = *req.Privacy
+	}
+
+	if err := s.repo.Update(ctx, folder); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
 func (s *Service) List(ctx context.Context) ([]*domain.Folder, error) {
 	return s.repo.List(ctx)
 }
@@ -50,5 +86,17 @@ func (s *Service) Get(ctx context.Context, id int64) (*domain.Folder, error) {
 }
 
 func (s *Service) Delete(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+	var folder *domain.Folder
+	if event.Subscribed[event.FolderDeleted](s.events) {
+		folder, _ = s.repo.GetByID(ctx, id)
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if folder != nil {
+		event.Publish(ctx, s.events, event.FolderDeleted{Folder: folder})
+	}
+	return nil
 }
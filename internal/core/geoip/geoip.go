@@ -0,0 +1,130 @@
+// Package geoip resolves client IP addresses to coarse locations using a
+// local MaxMind-format (.mmdb) database such as GeoLite2 City or DB-IP Lite.
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// record is the subset of the GeoLite2/DB-IP City schema we read. Country
+// databases only populate the country.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// DB is a reloadable GeoIP database. A nil *DB resolves nothing.
+type DB struct {
+	path string
+
+	mu     sync.RWMutex
+	reader *maxminddb.Reader
+}
+
+// Open loads the database at path.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path}
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Path returns the file backing this database.
+func (db *DB) Path() string {
+	return db.path
+}
+
+// Reload re-reads the database file. On error the previous data is kept.
+func (db *DB) Reload() error {
+	reader, err := maxminddb.Open(db.path)
+	if err != nil {
+		return fmt.Errorf("failed to open GeoIP database %s: %w", db.path, err)
+	}
+
+	db.mu.Lock()
+	old := db.reader
+	db.reader = reader
+	db.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Metadata describes the loaded database for logging.
+func (db *DB) Metadata() (databaseType string, nodes uint) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.reader.Metadata.DatabaseType, db.reader.Metadata.NodeCount
+}
+
+// Lookup resolves ip (optionally with a port) to a location. Unknown or
+// invalid addresses return an empty location.
+func (db *DB) Lookup(ip string) domain.GeoLocation {
+	if db == nil {
+		return domain.GeoLocation{}
+	}
+
+	parsed := parseIP(ip)
+	if parsed == nil {
+		return domain.GeoLocation{}
+	}
+
+	var rec record
+	db.mu.RLock()
+	err := db.reader.Lookup(parsed, &rec)
+	db.mu.RUnlock()
+	if err != nil {
+		return domain.GeoLocation{}
+	}
+
+	loc := domain.GeoLocation{
+		Country: strings.ToUpper(rec.Country.ISOCode),
+		City:    rec.City.Names["en"],
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = rec.Subdivisions[0].Names["en"]
+		if loc.Region == "" {
+			loc.Region = rec.Subdivisions[0].ISOCode
+		}
+	}
+	return loc
+}
+
+// Close releases the database.
+func (db *DB) Close() error {
+	if db == nil {
+		return nil
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.reader.Close()
+}
+
+func parseIP(s string) net.IP {
+	s = strings.TrimSpace(s)
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return net.ParseIP(host)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package link
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/event"
+	"github.com/aftaab/trelay/internal/core/port"
+)
+
+const (
+	expiryBatch = 100
+	// expiryCursorKey stores the expiry time and link ID up to which
+	// LinkExpired events have been published.
+	expiryCursorKey = "link_expiry_cursor"
+)
+
+// expiryPosition marks the last link published by the expiry watcher. Links
+// are read in expiry then ID order, so the ID resumes a page that ended
+// among links sharing an expiry time.
+type expiryPosition struct {
+	at time.Time
+	id int64
+}
+
+// SetConfigRepository lets the expiry watcher remember how far it got, so
+// links that expire while the server is down are still published after a
+// restart.
+func (s *Service) SetConfigRepository(config port.ConfigRepository) {
+	s.config = config
+}
+
+// PublishExpired publishes LinkExpired for live links whose expiry passed
+// since the last call. The first call only records where to start, so links
+// that expired long ago are not published. Nothing is read while nobody is
+// subscribed.
+func (s *Service) PublishExpired(ctx context.Context) error {
+	now := time.Now()
+
+	cursor, err := s.loadExpiryCursor(ctx)
+	if err != nil {
+		return err
+	}
+	if cursor.at.IsZero() || !event.Subscribed[event.LinkExpired](s.events) {
+		return s.saveExpiryCursor(ctx, expiryPosition{at: now})
+	}
+
+	for {
+		links, err := s.repo.ListExpiredBetween(ctx, cursor.at, cursor.id, now, expiryBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, link := range links {
+			event.Publish(ctx, s.events, event.LinkExpired{Link: link})
+		}
+
+		if len(links) < expiryBatch {
+			// Links expiring exactly at now were not read, and an ID of
+			// zero lets the next call pick them up.
+			return s.saveExpiryCursor(ctx, expiryPosition{at: now})
+		}
+		last := links[len(links)-1]
+		cursor = expiryPosition{at: *last.ExpiresAt, id: last.ID}
+		if err := s.saveExpiryCursor(ctx, cursor); err != nil {
+			return err
+		}
+	}
+}
+
+// RunExpiryWatcher publishes expired links once per interval until ctx is
+// cancelled.
+func (s *Service) RunExpiryWatcher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.PublishExpired(ctx)
+		}
+	}
+}
+
+func (s *Service) loadExpiryCursor(ctx context.Context) (expiryPosition, error) {
+	if s.config == nil {
+		return s.expiryCursor, nil
+	}
+
+	value, err := s.config.Get(ctx, expiryCursorKey)
+	if err != nil || value == "" {
+		return expiryPosition{}, err
+	}
+
+	at, id, _ := strings.Cut(value, " ")
+	t, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return expiryPosition{}, nil
+	}
+	// Expiry times are stored in server local time and compared as text.
+	cursor := expiryPosition{at: t.Local()}
+	if id != "" {
+		if cursor.id, err = strconv.ParseInt(id, 10, 64); err != nil {
+			return expiryPosition{}, nil
+		}
+	}
+	return cursor, nil
+}
+
+func (s *Service) saveExpiryCursor(ctx context.Context, cursor expiryPosition) error {
+	s.expiryCursor = cursor
+	if s.config == nil {
+		return nil
+	}
+	value := cursor.at.Format(time.RFC3339Nano) + " " + strconv.FormatInt(cursor.id, 10)
+	return s.config.Set(ctx, expiryCursorKey, value)
+}
@@ -0,0 +1,183 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+const (
+	healthCheckBatch           = 50
+	defaultHealthCheckParallel = 4
+	// healthCheckMaxTick bounds how long a new link waits for its first check.
+	healthCheckMaxTick = time.Hour
+)
+
+// SetHealthCheckConcurrency limits how many destinations are probed at once.
+func (s *Service) SetHealthCheckConcurrency(n int) {
+	s.checkConcurrency = n
+}
+
+// CheckLinks probes the destinations of the selected links, stores the
+// results and returns them in selection order. Slugs take precedence over
+// the folder and tag filters, whose matches are checked one page of at
+// most MaxLinkChecks links per call.
+func (s *Service) CheckLinks(ctx context.Context, req domain.CheckLinksRequest) ([]domain.LinkCheckResult, error) {
+	var links []*domain.Link
+	var missing []domain.LinkCheckResult
+
+	if len(req.Slugs) > 0 {
+		for _, slug := range req.Slugs {
+			link, err := s.repo.GetBySlug(ctx, slug)
+			if err == nil && link.IsDeleted() {
+				err = domain.ErrLinkDeleted
+			}
+			if err != nil {
+				if !errors.Is(err, domain.ErrLinkNotFound) && !errors.Is(err, domain.ErrLinkDeleted) {
+					return nil, err
+				}
+				missing = append(missing, domain.LinkCheckResult{Slug: slug, Error: err.Error()})
+				continue
+			}
+			links = append(links, link)
+		}
+	} else {
+		limit := req.Limit
+		if limit <= 0 || limit > domain.MaxLinkChecks {
+			limit = domain.MaxLinkChecks
+		}
+
+		var err error
+		links, err = s.repo.List(ctx, domain.ListLinksFilter{
+			FolderID: req.FolderID,
+			Tags:     req.Tags,
+			Limit:    limit,
+			Offset:   req.Offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := s.checkAll(ctx, links)
+	return append(results, missing...), nil
+}
+
+// CheckDueLinks probes one batch of links not checked since maxAge and
+// returns how many links were processed.
+func (s *Service) CheckDueLinks(ctx context.Context, maxAge time.Duration) (int, error) {
+	links, err := s.repo.ListDueForCheck(ctx, time.Now().Add(-maxAge), healthCheckBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	s.checkAll(ctx, links)
+	return len(links), ctx.Err()
+}
+
+// RunHealthChecker re-checks every live link once per interval until ctx is
+// cancelled. Full batches are followed immediately by another pass.
+func (s *Service) RunHealthChecker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	tick := interval
+	if tick > healthCheckMaxTick {
+		tick = healthCheckMaxTick
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := s.CheckDueLinks(ctx, interval)
+				if err != nil || n < healthCheckBatch {
+					break
+				}
+			}
+		}
+	}
+}
+
+// checkAll probes links with bounded concurrency.
+func (s *Service) checkAll(ctx context.Context, links []*domain.Link) []domain.LinkCheckResult {
+	limit := s.checkConcurrency
+	if limit <= 0 {
+		limit = defaultHealthCheckParallel
+	}
+
+	results := make([]domain.LinkCheckResult, len(links))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, l := range links {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return results[:i]
+		}
+
+		wg.Add(1)
+		go func(i int, l *domain.Link) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = s.checkLink(ctx, l)
+		}(i, l)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func (s *Service) checkLink(ctx context.Context, link *domain.Link) domain.LinkCheckResult {
+	now := time.Now()
+	health := &domain.LinkHealth{CheckedAt: &now}
+	result := domain.LinkCheckResult{Slug: link.Slug, URL: link.OriginalURL}
+
+	probe, err := s.urlValidator.Probe(ctx, link.OriginalURL)
+	if err != nil {
+		health.Error = err.Error()
+	} else {
+		health.StatusCode = probe.StatusCode
+		if probe.FinalURL != link.OriginalURL {
+			health.RedirectURL = probe.FinalURL
+		}
+		if isBrokenStatus(probe.StatusCode) {
+			health.Error = strings.ToLower(http.StatusText(probe.StatusCode))
+		}
+	}
+
+	result.StatusCode = health.StatusCode
+	result.RedirectURL = health.RedirectURL
+	result.Error = health.Error
+	result.Broken = health.Error != ""
+
+	// A cancelled check says nothing about the destination.
+	if ctx.Err() != nil {
+		return result
+	}
+
+	if err := s.repo.UpdateHealth(context.WithoutCancel(ctx), link.ID, link.OriginalURL, health, result.Broken); err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// isBrokenStatus reports whether a status means the destination is dead.
+// Auth walls and rate limits (401, 403, 429) are treated as alive.
+func isBrokenStatus(code int) bool {
+	return code == http.StatusNotFound || code == http.StatusGone || code >= 500
+}
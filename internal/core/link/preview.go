@@ -0,0 +1,91 @@
+package link
+
+import (
+	"context"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+const (
+	previewFetchTimeout = 30 * time.Second
+	previewRefreshBatch = 50
+)
+
+// PreviewFetcher fetches page metadata for a destination URL.
+type PreviewFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error)
+}
+
+// fetchPreviewAsync fetches and stores the preview for a link in the
+// background. The request context is not used since it ends with the response.
+func (s *Service) fetchPreviewAsync(link *domain.Link) {
+	if s.previews == nil {
+		return
+	}
+
+	linkID, destination := link.ID, link.OriginalURL
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), previewFetchTimeout)
+		defer cancel()
+		_ = s.refreshPreview(ctx, linkID, destination)
+	}()
+}
+
+func (s *Service) refreshPreview(ctx context.Context, linkID int64, destination string) error {
+	preview, err := s.previews.Fetch(ctx, destination)
+	if err != nil {
+		// Record the attempt without discarding a previously good preview.
+		preview = nil
+	}
+	return s.repo.UpdatePreview(ctx, linkID, destination, preview)
+}
+
+// RefreshStalePreviews refetches one batch of previews that are missing or
+// older than maxAge and returns how many links were processed.
+func (s *Service) RefreshStalePreviews(ctx context.Context, maxAge time.Duration) (int, error) {
+	if s.previews == nil {
+		return 0, nil
+	}
+
+	links, err := s.repo.ListStalePreviews(ctx, time.Now().Add(-maxAge), previewRefreshBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, l := range links {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		fetchCtx, cancel := context.WithTimeout(ctx, previewFetchTimeout)
+		_ = s.refreshPreview(fetchCtx, l.ID, l.OriginalURL)
+		cancel()
+	}
+
+	return len(links), nil
+}
+
+// RunPreviewRefresher refreshes stale previews every interval until ctx is
+// cancelled. Full batches are followed immediately by another pass.
+func (s *Service) RunPreviewRefresher(ctx context.Context, interval, maxAge time.Duration) {
+	if s.previews == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := s.RefreshStalePreviews(ctx, maxAge)
+				if err != nil || n < previewRefreshBatch {
+					break
+				}
+			}
+		}
+	}
+}
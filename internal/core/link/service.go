@@ -6,6 +6,7 @@ import (
 
 	"github.com/aftaab/trelay/internal/core/auth"
 	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/event"
 	"github.com/aftaab/trelay/internal/core/port"
 	"github.com/aftaab/trelay/internal/core/slug"
 	"github.com/aftaab/trelay/internal/core/url"
@@ -16,17 +17,42 @@ type Service struct {
 	repo         port.LinkRepository
 	slugGen      *slug.Generator
 	urlValidator *url.Validator
+	screener     *url.Screener
+	previews     PreviewFetcher
+	folders      port.FolderRepository
+
+	checkConcurrency int
+
+	events *event.Bus
+	// config remembers how far expired links have been published.
+	config       port.ConfigRepository
+	expiryCursor expiryPosition
 }
 
-// NewService creates a new link service.
-func NewService(repo port.LinkRepository, slugLength int, selfDomains []string) *Service {
+// NewService creates a new link service. screener may be nil to disable
+// destination safety screening, and previews may be nil to skip fetching
+// destination previews.
+func NewService(repo port.LinkRepository, slugLength int, selfDomains []string, screener *url.Screener, previews PreviewFetcher) *Service {
 	return &Service{
 		repo:         repo,
 		slugGen:      slug.NewGenerator(slugLength),
 		urlValidator: url.NewValidator(0, selfDomains),
+		screener:     screener,
+		previews:     previews,
 	}
 }
 
+// SetFolderRepository lets new links inherit their folder's analytics
+// privacy defaults.
+func (s *Service) SetFolderRepository(folders port.FolderRepository) {
+	s.folders = folders
+}
+
+// SetEventBus publishes link lifecycle events on bus.
+func (s *Service) SetEventBus(bus *event.Bus) {
+	s.events = bus
+}
+
 func (s *Service) Create(ctx context.Context, req domain.CreateLinkRequest) (*domain.Link, error) {
 	normalizedURL, err := s.urlValidator.Normalize(req.URL)
 	if err != nil {
@@ -37,6 +63,12 @@ func (s *Service) Create(ctx context.Context, req domain.CreateLinkRequest) (*do
 		return nil, err
 	}
 
+	if !req.SafetyOverride {
+		if err := s.screener.Screen(ctx, normalizedURL); err != nil {
+			return nil, err
+		}
+	}
+
 	linkSlug := req.Slug
 	if linkSlug == "" {
 		linkSlug, err = s.slugGen.Generate()
@@ -74,38 +106,50 @@ func (s *Service) Create(ctx context.Context, req domain.CreateLinkRequest) (*do
 
 	now := time.Now()
 	link := &domain.Link{
-		Slug:          linkSlug,
-		OriginalURL:   normalizedURL,
-		Domain:        req.Domain,
-		PasswordHash:  passwordHash,
-		HasPassword:   passwordHash != "",
-		IsOneTime:     req.IsOneTime,
-		ExpiresAt:     expiresAt,
-		Tags:          req.Tags,
-		FolderID:      req.FolderID,
-		OGTitle:       req.OGTitle,
-		OGDescription: req.OGDescription,
-		OGImageURL:    req.OGImageURL,
-		CreatedAt:     now,
-		UpdatedAt:     now,
-	}
-
-	return s.repo.Create(ctx, link)
-}
+		Slug:           linkSlug,
+		OriginalURL:    normalizedURL,
+		Domain:         req.Domain,
+		PasswordHash:   passwordHash,
+		HasPassword:    passwordHash != "",
+		IsOneTime:      req.IsOneTime,
+		ExpiresAt:      expiresAt,
+		Tags:           req.Tags,
+		FolderID:       req.FolderID,
+		OGTitle:        req.OGTitle,
+		OGDescription:  req.OGDescription,
+		OGImageURL:     req.OGImageURL,
+		SafetyOverride: req.SafetyOverride,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
 
-// Get retrieves a link by slug with optional password verification.
-func (s *Service) Get(ctx context.Context, linkSlug, password string) (*domain.Link, error) {
-	link, err := s.repo.GetBySlug(ctx, linkSlug)
+	if req.Privacy != nil {
+		link.Privacy = *req.Privacy
+	} else if req.FolderID != nil && s.folders != nil {
+		folder, err := s.folders.GetByID(ctx, *req.FolderID)
+		if err != nil && err != domain.ErrFolderNotFound {
+			return nil, err
+		}
+		if folder != nil {
+			link.Privacy = folder.Privacy
+		}
+	}
+
+	created, err := s.repo.Create(ctx, link)
 	if err != nil {
 		return nil, err
 	}
 
-	if link.IsDeleted() {
-		return nil, domain.ErrLinkDeleted
-	}
+	s.fetchPreviewAsync(created)
+	event.Publish(ctx, s.events, event.LinkCreated{Link: created})
+	return created, nil
+}
 
-	if link.IsExpired() {
-		return nil, domain.ErrLinkExpired
+// Get retrieves a link by slug with optional password verification.
+func (s *Service) Get(ctx context.Context, linkSlug, password string) (*domain.Link, error) {
+	link, err := s.GetLive(ctx, linkSlug)
+	if err != nil {
+		return nil, err
 	}
 
 	if link.HasPassword {
@@ -120,6 +164,25 @@ func (s *Service) Get(ctx context.Context, linkSlug, password string) (*domain.L
 	return link, nil
 }
 
+// GetLive retrieves a link by slug unless it is deleted or expired. It skips
+// the visitor password check, so it is for the link's owner only.
+func (s *Service) GetLive(ctx context.Context, linkSlug string) (*domain.Link, error) {
+	link, err := s.repo.GetBySlug(ctx, linkSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.IsDeleted() {
+		return nil, domain.ErrLinkDeleted
+	}
+
+	if link.IsExpired() {
+		return nil, domain.ErrLinkExpired
+	}
+
+	return link, nil
+}
+
 func (s *Service) GetForRedirect(ctx context.Context, linkSlug string) (*domain.Link, error) {
 	link, err := s.repo.GetBySlug(ctx, linkSlug)
 	if err != nil {
@@ -152,6 +215,20 @@ func (s *Service) Update(ctx context.Context, linkSlug string, req domain.Update
 	if err != nil {
 		return nil, err
 	}
+	previousURL := link.OriginalURL
+	wasOverridden := link.SafetyOverride
+
+	if req.SafetyOverride != nil {
+		link.SafetyOverride = *req.SafetyOverride
+	}
+
+	// Dropping the override puts the current destination back under
+	// screening; a new URL is screened below.
+	if req.URL == nil && wasOverridden && !link.SafetyOverride {
+		if err := s.screener.Screen(ctx, link.OriginalURL); err != nil {
+			return nil, err
+		}
+	}
 
 	if req.URL != nil {
 		normalizedURL, err := s.urlValidator.Normalize(*req.URL)
@@ -161,6 +238,11 @@ func (s *Service) Update(ctx context.Context, linkSlug string, req domain.Update
 		if err := s.urlValidator.Validate(normalizedURL); err != nil {
 			return nil, err
 		}
+		if !link.SafetyOverride {
+			if err := s.screener.Screen(ctx, normalizedURL); err != nil {
+				return nil, err
+			}
+		}
 		link.OriginalURL = normalizedURL
 	}
 
@@ -205,12 +287,27 @@ func (s *Service) Update(ctx context.Context, linkSlug string, req domain.Update
 		link.OGImageURL = *req.OGImageURL
 	}
 
+	if req.Privacy != nil {
+		link.Privacy = *req.Privacy
+	}
+
 	link.UpdatedAt = time.Now()
 
+	// Health results and the preview describe the old destination.
+	if link.OriginalURL != previousURL {
+		link.Health = nil
+		link.Preview = nil
+	}
+
 	if err := s.repo.Update(ctx, link); err != nil {
 		return nil, err
 	}
 
+	if link.OriginalURL != previousURL {
+		s.fetchPreviewAsync(link)
+	}
+
+	event.Publish(ctx, s.events, event.LinkUpdated{Link: link})
 	return link, nil
 }
 
@@ -273,6 +370,7 @@ func (s *Service) BulkUpdate(ctx context.Context, req domain.BulkUpdateLinksRequ
 			continue
 		}
 		result.Updated = append(result.Updated, slug)
+		event.Publish(ctx, s.events, event.LinkUpdated{Link: link})
 	}
 
 	return result, nil
@@ -280,17 +378,50 @@ func (s *Service) BulkUpdate(ctx context.Context, req domain.BulkUpdateLinksRequ
 
 // Delete soft-deletes a link.
 func (s *Service) Delete(ctx context.Context, linkSlug string) error {
-	return s.repo.Delete(ctx, linkSlug)
+	if err := s.repo.Delete(ctx, linkSlug); err != nil {
+		return err
+	}
+	if event.Subscribed[event.LinkDeleted](s.events) {
+		if link, err := s.repo.GetBySlug(ctx, linkSlug); err == nil {
+			event.Publish(ctx, s.events, event.LinkDeleted{Link: link})
+		}
+	}
+	return nil
 }
 
 // HardDelete permanently removes a link.
 func (s *Service) HardDelete(ctx context.Context, linkSlug string) error {
-	return s.repo.HardDelete(ctx, linkSlug)
+	var link *domain.Link
+	if event.Subscribed[event.LinkDeleted](s.events) {
+		link, _ = s.repo.GetBySlug(ctx, linkSlug)
+	}
+
+	if err := s.repo.HardDelete(ctx, linkSlug); err != nil {
+		return err
+	}
+
+	if link != nil {
+		e := event.LinkDeleted{Link: link, Permanent: true, Trashed: link.IsDeleted()}
+		if !e.Trashed {
+			now := time.Now()
+			link.DeletedAt = &now
+		}
+		event.Publish(ctx, s.events, e)
+	}
+	return nil
 }
 
 // Restore recovers a soft-deleted link.
 func (s *Service) Restore(ctx context.Context, linkSlug string) error {
-	return s.repo.Restore(ctx, linkSlug)
+	if err := s.repo.Restore(ctx, linkSlug); err != nil {
+		return err
+	}
+	if event.Subscribed[event.LinkRestored](s.events) {
+		if link, err := s.repo.GetBySlug(ctx, linkSlug); err == nil {
+			event.Publish(ctx, s.events, event.LinkRestored{Link: link})
+		}
+	}
+	return nil
 }
 
 // List retrieves links matching filter criteria.
@@ -316,5 +447,13 @@ func (s *Service) IncrementClick(ctx context.Context, linkID int64) error {
 
 // Burn marks a one-time link as consumed (soft-delete).
 func (s *Service) Burn(ctx context.Context, linkID int64) error {
-	return s.repo.Burn(ctx, linkID)
+	if err := s.repo.Burn(ctx, linkID); err != nil {
+		return err
+	}
+	if event.Subscribed[event.LinkBurned](s.events) {
+		if link, err := s.repo.GetByID(ctx, linkID); err == nil {
+			event.Publish(ctx, s.events, event.LinkBurned{Link: link})
+		}
+	}
+	return nil
 }
@@ -0,0 +1,175 @@
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrBlockedDestination is returned when a request would connect to a
+	// private, loopback, link-local or metadata address, or a disallowed port.
+	ErrBlockedDestination = errors.New("destination address is not allowed")
+
+	// ErrResponseTooLarge is returned when a response body exceeds the limit.
+	ErrResponseTooLarge = errors.New("response body too large")
+
+	// ErrTooManyRedirects is returned when a redirect chain exceeds the limit.
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// DefaultAllowedPorts are the destination ports outbound fetches may use.
+var DefaultAllowedPorts = []int{80, 443, 8080, 8443}
+
+const (
+	defaultTimeout      = 10 * time.Second
+	defaultMaxBodyBytes = 1024 * 1024 // 1MB
+	defaultMaxRedirects = 5
+	dialTimeout         = 5 * time.Second
+	maxHeaderBytes      = 64 * 1024
+)
+
+// ClientOptions configures a hardened HTTP client.
+type ClientOptions struct {
+	// Timeout bounds the whole request including redirects and body read.
+	Timeout time.Duration
+	// MaxBodyBytes caps every response body; reads past it fail.
+	MaxBodyBytes int64
+	// MaxRedirects caps the redirect chain. Zero uses the default.
+	MaxRedirects int
+	// AllowedPorts restricts destination ports. Empty uses DefaultAllowedPorts.
+	AllowedPorts []int
+}
+
+// NewClient returns an HTTP client for fetching user-supplied URLs. Every
+// connection, including those made while following redirects, is checked at
+// dial time against the resolved IP so DNS rebinding cannot reach internal hosts.
+func NewClient(opts ClientOptions) *http.Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = defaultMaxRedirects
+	}
+	if len(opts.AllowedPorts) == 0 {
+		opts.AllowedPorts = DefaultAllowedPorts
+	}
+
+	ports := make(map[int]bool, len(opts.AllowedPorts))
+	for _, port := range opts.AllowedPorts {
+		ports[port] = true
+	}
+
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return checkDialAddress(address, ports)
+		},
+	}
+
+	transport := &http.Transport{
+		// Never use an environment proxy: it would bypass the dial-time checks.
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		ForceAttemptHTTP2:      true,
+		MaxIdleConns:           20,
+		IdleConnTimeout:        30 * time.Second,
+		TLSHandshakeTimeout:    dialTimeout,
+		ResponseHeaderTimeout:  opts.Timeout,
+		ExpectContinueTimeout:  time.Second,
+		MaxResponseHeaderBytes: maxHeaderBytes,
+	}
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &limitedTransport{next: transport, maxBytes: opts.MaxBodyBytes},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= opts.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s scheme", ErrBlockedDestination, req.URL.Scheme)
+			}
+			if port := req.URL.Port(); port != "" {
+				if p, err := strconv.Atoi(port); err != nil || !ports[p] {
+					return fmt.Errorf("%w: port %s", ErrBlockedDestination, port)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func checkDialAddress(address string, ports map[int]bool) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, address)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || !ports[port] {
+		return fmt.Errorf("%w: port %s", ErrBlockedDestination, portStr)
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil || IsBlockedAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, host)
+	}
+
+	return nil
+}
+
+// limitedTransport caps response body size for every response it returns.
+// Reading past the cap fails with ErrResponseTooLarge; callers that only need
+// a prefix (e.g. an HTML head) can still wrap the body in an io.LimitReader.
+type limitedTransport struct {
+	next     http.RoundTripper
+	maxBytes int64
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxBytes}
+	return resp, nil
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Distinguish "exactly at the limit" from "over the limit".
+		var probe [1]byte
+		if n, _ := b.ReadCloser.Read(probe[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// IsBlocked reports whether err was caused by a refused destination.
+func IsBlocked(err error) bool {
+	return errors.Is(err, ErrBlockedDestination)
+}
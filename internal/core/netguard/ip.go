@@ -0,0 +1,66 @@
+package netguard
+
+import (
+	"net"
+	"net/netip"
+)
+
+// blockedPrefixes are address ranges that must never be reached on behalf of
+// a user: private networks, loopback, link-local and cloud metadata services.
+var blockedPrefixes = mustParsePrefixes(
+	"0.0.0.0/8",          // "this" network
+	"10.0.0.0/8",         // private
+	"100.64.0.0/10",      // carrier-grade NAT (also Alibaba metadata 100.100.100.200)
+	"127.0.0.0/8",        // loopback
+	"169.254.0.0/16",     // link-local (AWS/GCP/Azure metadata 169.254.169.254)
+	"172.16.0.0/12",      // private
+	"192.0.0.0/24",       // IETF protocol assignments
+	"192.0.2.0/24",       // TEST-NET-1
+	"192.168.0.0/16",     // private
+	"198.18.0.0/15",      // benchmarking
+	"198.51.100.0/24",    // TEST-NET-2
+	"203.0.113.0/24",     // TEST-NET-3
+	"224.0.0.0/4",        // multicast
+	"240.0.0.0/4",        // reserved
+	"255.255.255.255/32", // broadcast
+	"::/128",             // unspecified
+	"::1/128",            // loopback
+	"64:ff9b::/96",       // NAT64 (can map onto private IPv4)
+	"100::/64",           // discard
+	"2001:db8::/32",      // documentation
+	"fc00::/7",           // unique local (AWS metadata fd00:ec2::254)
+	"fe80::/10",          // link-local
+	"ff00::/8",           // multicast
+)
+
+func mustParsePrefixes(cidrs ...string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefixes = append(prefixes, netip.MustParsePrefix(cidr))
+	}
+	return prefixes
+}
+
+// IsBlockedAddr reports whether addr is in a private, loopback, link-local,
+// metadata or otherwise non-routable range.
+func IsBlockedAddr(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return true
+	}
+	addr = addr.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBlockedIP is IsBlockedAddr for net.IP values.
+func IsBlockedIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	return IsBlockedAddr(addr)
+}
@@ -2,6 +2,7 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/aftaab/trelay/internal/core/domain"
 )
@@ -41,29 +42,93 @@ type LinkRepository interface {
 	// IncrementClickCount atomically increments the click count for a link.
 	IncrementClickCount(ctx context.Context, linkID int64) error
 
+	// AddClickCounts adds several links' click increments in one transaction.
+	AddClickCounts(ctx context.Context, counts map[int64]int64) error
+
 	// Burn marks a one-time link as used (soft-delete).
 	Burn(ctx context.Context, linkID int64) error
+
+	// UpdatePreview stores fetched preview metadata if the link still points at forURL.
+	// A nil preview only records the fetch attempt time.
+	UpdatePreview(ctx context.Context, linkID int64, forURL string, preview *domain.LinkPreview) error
+
+	// ListStalePreviews retrieves live links whose preview is missing or older than fetchedBefore.
+	ListStalePreviews(ctx context.Context, fetchedBefore time.Time, limit int) ([]*domain.Link, error)
+
+	// UpdateHealth records a destination check result if the link still points at forURL.
+	// failed extends the failure streak; otherwise it is reset.
+	UpdateHealth(ctx context.Context, linkID int64, forURL string, health *domain.LinkHealth, failed bool) error
+
+	// ListDueForCheck retrieves live links never checked or last checked before checkedBefore.
+	ListDueForCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]*domain.Link, error)
+
+	// ListExpiredBetween retrieves live links expiring before before and after the (after, afterID) cursor, in expiry then ID order.
+	ListExpiredBetween(ctx context.Context, after time.Time, afterID int64, before time.Time, limit int) ([]*domain.Link, error)
 }
 
 // ClickRepository defines the interface for click/analytics persistence.
+// Stats methods taking a linkID aggregate over every link when it is zero.
 type ClickRepository interface {
 	// Record stores a new click event.
 	Record(ctx context.Context, click *domain.Click) error
 
-	// GetByLinkID retrieves all clicks for a specific link.
-	GetByLinkID(ctx context.Context, linkID int64, filter domain.StatsFilter) ([]*domain.Click, error)
+	// RecordBatch stores several click events in one transaction.
+	RecordBatch(ctx context.Context, clicks []*domain.Click) error
+
+	// GetByLinkID retrieves up to limit clicks for a link, or for every link
+	// when linkID is 0, with IDs above afterID in ascending order.
+	GetByLinkID(ctx context.Context, linkID int64, filter domain.StatsFilter, afterID int64, limit int) ([]*domain.Click, error)
+
+	// RecordConversion stores a conversion for the click with the given
+	// token, filling in conv's click, link and channel. It returns
+	// domain.ErrClickNotFound if no click has the token.
+	RecordConversion(ctx context.Context, conv *domain.Conversion, token string) error
 
 	// GetStatsByLinkID retrieves aggregated stats for a link.
 	GetStatsByLinkID(ctx context.Context, linkID int64, filter domain.StatsFilter) (*domain.ClickStats, error)
 
+	// GetWorkspaceStats retrieves stats aggregated across links matching the filter.
+	GetWorkspaceStats(ctx context.Context, days, limit int, filter domain.StatsFilter) (*domain.WorkspaceStats, error)
+
+	// GetTopLinks retrieves the most clicked links matching the filter.
+	GetTopLinks(ctx context.Context, limit int, filter domain.StatsFilter) ([]domain.LinkClickStats, error)
+
+	// GetClicksByHour retrieves hourly click counts for a link.
+	GetClicksByHour(ctx context.Context, linkID int64, hours int, filter domain.StatsFilter) ([]domain.HourStats, error)
+
 	// GetClicksByDay retrieves daily click counts for a link.
-	GetClicksByDay(ctx context.Context, linkID int64, days int) ([]domain.DayStats, error)
+	GetClicksByDay(ctx context.Context, linkID int64, days int, filter domain.StatsFilter) ([]domain.DayStats, error)
 
 	// GetClicksByMonth retrieves monthly click counts for a link.
-	GetClicksByMonth(ctx context.Context, linkID int64, months int) ([]domain.MonthStats, error)
+	GetClicksByMonth(ctx context.Context, linkID int64, months int, filter domain.StatsFilter) ([]domain.MonthStats, error)
 
 	// GetTopReferrers retrieves the most common referrers for a link.
-	GetTopReferrers(ctx context.Context, linkID int64, limit int) ([]domain.ReferrerStats, error)
+	GetTopReferrers(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.ReferrerStats, error)
+
+	// GetTopCountries retrieves the countries with the most clicks for a link.
+	GetTopCountries(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.CountryStats, error)
+
+	// GetTopCities retrieves the cities with the most clicks for a link.
+	GetTopCities(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.CityStats, error)
+
+	// GetTopBrowsers retrieves the browser families with the most clicks for a link.
+	GetTopBrowsers(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.BrowserStats, error)
+
+	// GetTopOS retrieves the operating system families with the most clicks for a link.
+	GetTopOS(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.OSStats, error)
+
+	// GetDeviceTypes retrieves click counts per device class for a link.
+	GetDeviceTypes(ctx context.Context, linkID int64, filter domain.StatsFilter) ([]domain.DeviceStats, error)
+
+	// GetChannels retrieves click counts per referrer channel with the top hosts of each.
+	GetChannels(ctx context.Context, linkID int64, hostsPerChannel int, filter domain.StatsFilter) ([]domain.ChannelStats, error)
+
+	// GetSources retrieves click counts per click source (qr, direct, api) for a link.
+	GetSources(ctx context.Context, linkID int64, filter domain.StatsFilter) ([]domain.SourceStats, error)
+
+	// PruneClicks deletes raw clicks recorded before the given time, keeping
+	// their daily rollups, and returns how many were deleted.
+	PruneClicks(ctx context.Context, before time.Time) (int64, error)
 
 	// DeleteByLinkID removes all clicks for a link (for GDPR compliance).
 	DeleteByLinkID(ctx context.Context, linkID int64) error
@@ -74,7 +139,51 @@ type FolderRepository interface {
 	Create(ctx context.Context, folder *domain.Folder) (*domain.Folder, error)
 	GetByID(ctx context.Context, id int64) (*domain.Folder, error)
 	List(ctx context.Context) ([]*domain.Folder, error)
+	Update(ctx context.Context, folder *domain.Folder) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// ReportRepository defines the interface for scheduled report persistence.
+type ReportRepository interface {
+	Create(ctx context.Context, report *domain.Report) (*domain.Report, error)
+	GetByID(ctx context.Context, id int64) (*domain.Report, error)
+	List(ctx context.Context) ([]*domain.Report, error)
+	Update(ctx context.Context, report *domain.Report) error
+	Delete(ctx context.Context, id int64) error
+
+	// ListDue retrieves up to limit enabled reports due to run at now.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Report, error)
+
+	// RecordRun stores the outcome of a run and, unless nextRunAt is nil,
+	// when the report runs next.
+	RecordRun(ctx context.Context, id int64, ranAt time.Time, runErr string, nextRunAt *time.Time) error
+}
+
+// WebhookRepository defines the interface for webhook subscriptions and
+// their delivery queue.
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error)
+	GetByID(ctx context.Context, id int64) (*domain.Webhook, error)
+	List(ctx context.Context) ([]*domain.Webhook, error)
+	Update(ctx context.Context, webhook *domain.Webhook) error
 	Delete(ctx context.Context, id int64) error
+
+	// Enqueue stores new deliveries, filling in their IDs, and skips those to deleted webhooks.
+	Enqueue(ctx context.Context, deliveries []*domain.WebhookDelivery) error
+
+	// ListDueDeliveries retrieves up to limit pending deliveries to enabled
+	// webhooks due to be attempted at now.
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error)
+
+	// ListDeliveries retrieves a webhook's deliveries, newest first.
+	ListDeliveries(ctx context.Context, webhookID int64, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+
+	// UpdateDelivery stores the outcome of a delivery attempt.
+	UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+
+	// PruneDeliveries deletes deliveries completed before the given time
+	// and returns how many were deleted.
+	PruneDeliveries(ctx context.Context, before time.Time) (int64, error)
 }
 
 // ConfigRepository defines the interface for application config persistence.
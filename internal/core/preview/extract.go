@@ -0,0 +1,299 @@
+package preview
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const maxJSONLDSize = 256 * 1024
+
+// metadata holds everything collected from a single pass over a document.
+type metadata struct {
+	base *url.URL
+
+	title   string
+	meta    map[string]string
+	icons   []string
+	touch   []string
+	oembed  string
+	canon   string
+	jsonLD  []ldObject
+	inTitle bool
+}
+
+// ldObject is the subset of a schema.org JSON-LD node we care about.
+type ldObject struct {
+	Name        string
+	Headline    string
+	Description string
+	Image       string
+}
+
+// extract tokenizes an HTML document and collects preview metadata. Relative
+// URLs are resolved against pageURL (or <base href> when present).
+func extract(r io.Reader, pageURL *url.URL) *metadata {
+	m := &metadata{base: pageURL, meta: make(map[string]string)}
+	z := html.NewTokenizer(r)
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return m
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			a := atom.Lookup(name)
+			var attrs map[string]string
+			if hasAttr {
+				attrs = readAttrs(z)
+			}
+
+			switch a {
+			case atom.Title:
+				if m.title == "" {
+					m.inTitle = tt == html.StartTagToken
+				}
+			case atom.Base:
+				if href := attrs["href"]; href != "" {
+					if u := m.resolve(href); u != "" {
+						if parsed, err := url.Parse(u); err == nil {
+							m.base = parsed
+						}
+					}
+				}
+			case atom.Meta:
+				m.readMeta(attrs)
+			case atom.Link:
+				m.readLink(attrs)
+			case atom.Script:
+				if strings.EqualFold(strings.TrimSpace(attrs["type"]), "application/ld+json") && tt == html.StartTagToken {
+					m.readJSONLD(z)
+				}
+			}
+
+		case html.TextToken:
+			if m.inTitle {
+				m.title += string(z.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if atom.Lookup(name) == atom.Title {
+				m.inTitle = false
+			}
+		}
+	}
+}
+
+func readAttrs(z *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		key, val, more := z.TagAttr()
+		k := strings.ToLower(string(key))
+		if _, exists := attrs[k]; !exists {
+			attrs[k] = string(val)
+		}
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// readMeta records Open Graph, Twitter Card and standard meta tags. Sites
+// mix property= and name= for both OG and Twitter, so both are accepted.
+func (m *metadata) readMeta(attrs map[string]string) {
+	content := strings.TrimSpace(attrs["content"])
+	if content == "" {
+		return
+	}
+
+	for _, attr := range []string{"property", "name", "itemprop"} {
+		key := strings.ToLower(strings.TrimSpace(attrs[attr]))
+		if key == "" {
+			continue
+		}
+		if _, exists := m.meta[key]; !exists {
+			m.meta[key] = content
+		}
+	}
+}
+
+func (m *metadata) readLink(attrs map[string]string) {
+	href := strings.TrimSpace(attrs["href"])
+	if href == "" {
+		return
+	}
+
+	rels := strings.Fields(strings.ToLower(attrs["rel"]))
+	for _, rel := range rels {
+		switch rel {
+		case "icon":
+			m.icons = append(m.icons, m.resolve(href))
+		case "apple-touch-icon", "apple-touch-icon-precomposed":
+			m.touch = append(m.touch, m.resolve(href))
+		case "canonical":
+			if m.canon == "" {
+				m.canon = m.resolve(href)
+			}
+		case "alternate":
+			if m.oembed == "" && strings.EqualFold(attrs["type"], "application/json+oembed") {
+				m.oembed = m.resolve(href)
+			}
+		}
+	}
+}
+
+func (m *metadata) readJSONLD(z *html.Tokenizer) {
+	var raw strings.Builder
+	for {
+		tt := z.Next()
+		if tt != html.TextToken {
+			break
+		}
+		text := z.Text()
+		if raw.Len()+len(text) > maxJSONLDSize {
+			return
+		}
+		raw.Write(text)
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal([]byte(raw.String()), &doc); err != nil {
+		return
+	}
+	m.walkJSONLD(doc, 0)
+}
+
+func (m *metadata) walkJSONLD(node interface{}, depth int) {
+	if depth > 4 {
+		return
+	}
+
+	switch v := node.(type) {
+	case []interface{}:
+		for _, item := range v {
+			m.walkJSONLD(item, depth+1)
+		}
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			m.walkJSONLD(graph, depth+1)
+		}
+		obj := ldObject{
+			Name:        ldString(v["name"]),
+			Headline:    ldString(v["headline"]),
+			Description: ldString(v["description"]),
+			Image:       ldImage(v["image"]),
+		}
+		if obj.Image == "" {
+			obj.Image = ldImage(v["thumbnailUrl"])
+		}
+		if obj != (ldObject{}) {
+			obj.Image = m.resolve(obj.Image)
+			m.jsonLD = append(m.jsonLD, obj)
+		}
+	}
+}
+
+func ldString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strings.TrimSpace(s)
+	}
+	return ""
+}
+
+// ldImage handles the string, ImageObject and array forms of schema.org image.
+func ldImage(v interface{}) string {
+	switch img := v.(type) {
+	case string:
+		return img
+	case map[string]interface{}:
+		if u := ldString(img["url"]); u != "" {
+			return u
+		}
+		return ldString(img["contentUrl"])
+	case []interface{}:
+		for _, item := range img {
+			if u := ldImage(item); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// resolve turns a possibly relative reference into an absolute http(s) URL.
+func (m *metadata) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if m.base != nil {
+		parsed = m.base.ResolveReference(parsed)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ""
+	}
+	return parsed.String()
+}
+
+// first returns the first non-empty meta value among keys.
+func (m *metadata) first(keys ...string) string {
+	for _, key := range keys {
+		if v := m.meta[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (m *metadata) ldTitle() string {
+	for _, obj := range m.jsonLD {
+		if obj.Headline != "" {
+			return obj.Headline
+		}
+		if obj.Name != "" {
+			return obj.Name
+		}
+	}
+	return ""
+}
+
+func (m *metadata) ldDescription() string {
+	for _, obj := range m.jsonLD {
+		if obj.Description != "" {
+			return obj.Description
+		}
+	}
+	return ""
+}
+
+func (m *metadata) ldImage() string {
+	for _, obj := range m.jsonLD {
+		if obj.Image != "" {
+			return obj.Image
+		}
+	}
+	return ""
+}
+
+func (m *metadata) favicon() string {
+	for _, icon := range append(m.icons, m.touch...) {
+		if icon != "" {
+			return icon
+		}
+	}
+	if m.base == nil {
+		return ""
+	}
+	return (&url.URL{Scheme: m.base.Scheme, Host: m.base.Host, Path: "/favicon.ico"}).String()
+}
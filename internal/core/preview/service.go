@@ -2,66 +2,62 @@ package preview
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
-	"regexp"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/netguard"
 )
 
 const (
 	fetchTimeout   = 10 * time.Second
 	maxBodySize    = 1024 * 1024 // 1MB
+	maxOEmbedSize  = 64 * 1024
 	maxTitleLen    = 200
 	maxDescLen     = 500
 	maxImageURLLen = 2048
+	maxNameLen     = 100
+	userAgent      = "Trelay/1.0 (Link Preview)"
 )
 
-var (
-	titleRegex    = regexp.MustCompile(`(?i)<title[^>]*>([^<]+)</title>`)
-	ogTitleRegex  = regexp.MustCompile(`(?i)<meta[^>]+property=["']og:title["'][^>]+content=["']([^"']+)["']`)
-	ogTitleRegex2 = regexp.MustCompile(`(?i)<meta[^>]+content=["']([^"']+)["'][^>]+property=["']og:title["']`)
-	ogDescRegex   = regexp.MustCompile(`(?i)<meta[^>]+property=["']og:description["'][^>]+content=["']([^"']+)["']`)
-	ogDescRegex2  = regexp.MustCompile(`(?i)<meta[^>]+content=["']([^"']+)["'][^>]+property=["']og:description["']`)
-	ogImageRegex  = regexp.MustCompile(`(?i)<meta[^>]+property=["']og:image["'][^>]+content=["']([^"']+)["']`)
-	ogImageRegex2 = regexp.MustCompile(`(?i)<meta[^>]+content=["']([^"']+)["'][^>]+property=["']og:image["']`)
-	descRegex     = regexp.MustCompile(`(?i)<meta[^>]+name=["']description["'][^>]+content=["']([^"']+)["']`)
-	descRegex2    = regexp.MustCompile(`(?i)<meta[^>]+content=["']([^"']+)["'][^>]+name=["']description["']`)
-)
-
-type Preview struct {
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	ImageURL    string `json:"image_url,omitempty"`
-	FetchedAt   time.Time `json:"fetched_at"`
-}
-
 type Service struct {
 	client *http.Client
 }
 
 func NewService() *Service {
 	return &Service{
-		client: &http.Client{
-			Timeout: fetchTimeout,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= 5 {
-					return http.ErrUseLastResponse
-				}
-				return nil
-			},
-		},
+		client: netguard.NewClient(netguard.ClientOptions{
+			Timeout:      fetchTimeout,
+			MaxBodyBytes: maxBodySize,
+			MaxRedirects: 5,
+		}),
 	}
 }
 
-func (s *Service) Fetch(ctx context.Context, url string) (*Preview, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// Fetch downloads rawURL and extracts Open Graph, Twitter Card, JSON-LD,
+// oEmbed and favicon metadata from it.
+func (s *Service) Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("%w: unsupported URL", netguard.ErrBlockedDestination)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "Trelay/1.0 (Link Preview)")
-	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -70,82 +66,114 @@ func (s *Service) Fetch(ctx context.Context, url string) (*Preview, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return &Preview{FetchedAt: time.Now()}, nil
+		return &domain.LinkPreview{FetchedAt: time.Now()}, nil
 	}
 
 	contentType := resp.Header.Get("Content-Type")
-	if !strings.Contains(contentType, "text/html") {
-		return &Preview{FetchedAt: time.Now()}, nil
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return &domain.LinkPreview{FetchedAt: time.Now()}, nil
 	}
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	// Decode to UTF-8 using the Content-Type charset, a BOM, or a <meta charset>.
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxBodySize), contentType)
 	if err != nil {
 		return nil, err
 	}
 
-	html := string(body)
-	preview := &Preview{
-		Title:       extractTitle(html),
-		Description: extractDescription(html),
-		ImageURL:    extractImage(html),
-		FetchedAt:   time.Now(),
+	m := extract(body, resp.Request.URL)
+
+	preview := &domain.LinkPreview{
+		Title:        clean(firstNonEmpty(m.first("og:title", "twitter:title"), m.ldTitle(), m.title), maxTitleLen),
+		Description:  clean(firstNonEmpty(m.first("og:description", "twitter:description", "description"), m.ldDescription()), maxDescLen),
+		ImageURL:     truncate(firstNonEmpty(m.resolve(m.first("og:image", "og:image:url", "og:image:secure_url", "twitter:image", "twitter:image:src", "image")), m.ldImage()), maxImageURLLen),
+		SiteName:     clean(m.first("og:site_name", "application-name", "twitter:site"), maxNameLen),
+		Type:         clean(m.first("og:type"), maxNameLen),
+		CanonicalURL: truncate(firstNonEmpty(m.resolve(m.first("og:url")), m.canon), maxImageURLLen),
+		FaviconURL:   truncate(m.favicon(), maxImageURLLen),
+		OEmbedURL:    truncate(m.oembed, maxImageURLLen),
+		TwitterCard:  clean(m.first("twitter:card"), maxNameLen),
+		FetchedAt:    time.Now(),
+	}
+
+	if preview.OEmbedURL != "" && (preview.Title == "" || preview.ImageURL == "") {
+		s.fillFromOEmbed(ctx, preview)
 	}
 
 	return preview, nil
 }
 
-func extractTitle(html string) string {
-	if matches := ogTitleRegex.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxTitleLen)
+type oembedResponse struct {
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Type         string `json:"type"`
+	URL          string `json:"url"`
+}
+
+// fillFromOEmbed fills gaps in the preview from the discovered oEmbed endpoint.
+// Failures are ignored; oEmbed is best-effort.
+func (s *Service) fillFromOEmbed(ctx context.Context, preview *domain.LinkPreview) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, preview.OEmbedURL, nil)
+	if err != nil {
+		return
 	}
-	if matches := ogTitleRegex2.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxTitleLen)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return
 	}
-	if matches := titleRegex.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxTitleLen)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return
 	}
-	return ""
-}
 
-func extractDescription(html string) string {
-	if matches := ogDescRegex.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxDescLen)
+	var data oembedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOEmbedSize)).Decode(&data); err != nil {
+		return
 	}
-	if matches := ogDescRegex2.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxDescLen)
+
+	if preview.Title == "" {
+		preview.Title = clean(data.Title, maxTitleLen)
 	}
-	if matches := descRegex.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxDescLen)
+	if preview.ImageURL == "" {
+		image := data.ThumbnailURL
+		if image == "" && data.Type == "photo" {
+			image = data.URL
+		}
+		if u, err := url.Parse(image); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			preview.ImageURL = truncate(image, maxImageURLLen)
+		}
 	}
-	if matches := descRegex2.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxDescLen)
+	if preview.SiteName == "" {
+		preview.SiteName = clean(data.ProviderName, maxNameLen)
 	}
-	return ""
 }
 
-func extractImage(html string) string {
-	if matches := ogImageRegex.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxImageURLLen)
-	}
-	if matches := ogImageRegex2.FindStringSubmatch(html); len(matches) > 1 {
-		return truncate(decodeHTML(matches[1]), maxImageURLLen)
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
 	}
 	return ""
 }
 
-func decodeHTML(s string) string {
-	s = strings.ReplaceAll(s, "&amp;", "&")
-	s = strings.ReplaceAll(s, "&lt;", "<")
-	s = strings.ReplaceAll(s, "&gt;", ">")
-	s = strings.ReplaceAll(s, "&quot;", "\"")
-	s = strings.ReplaceAll(s, "&#39;", "'")
-	s = strings.ReplaceAll(s, "&apos;", "'")
-	return strings.TrimSpace(s)
+// clean collapses whitespace and truncates. Text from the tokenizer is
+// already unescaped, so entities left in it are literal and kept as is.
+func clean(s string, maxLen int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return truncate(s, maxLen)
 }
 
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if utf8.RuneCountInString(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	runes := []rune(s)
+	return string(runes[:maxLen-3]) + "..."
 }
@@ -0,0 +1,346 @@
+// Package qr renders QR codes for short links as PNG or SVG, with custom
+// colours, quiet zone, error correction and an optional centred logo.
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// Format is the image format of a rendered code.
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// ContentType returns the MIME type of f.
+func (f Format) ContentType() string {
+	if f == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Limits and defaults of Options.
+const (
+	DefaultSize   = 256
+	MinSize       = 64
+	MaxSize       = 4096
+	DefaultMargin = 4
+	MaxMargin     = 16
+)
+
+// logoFraction is the logo's width as a share of the code, without the
+// quiet zone. At a fifth the logo hides about 4% of the modules, well
+// within what even medium error correction recovers.
+const logoFraction = 5
+
+// levels maps error correction names to their recovery levels.
+var levels = map[string]qrcode.RecoveryLevel{
+	"low":      qrcode.Low,
+	"medium":   qrcode.Medium,
+	"quartile": qrcode.High,
+	"high":     qrcode.Highest,
+}
+
+// Options controls how a code is rendered. Zero values select defaults.
+type Options struct {
+	Format Format
+	// Size is the width and height in pixels. PNG modules are whole
+	// pixels, so the code is centred within Size and may leave a slightly
+	// wider margin.
+	Size int
+	// Margin is the quiet zone around the code in modules; nil means
+	// DefaultMargin.
+	Margin *int
+	// Level is the error correction level: low, medium, quartile or high.
+	// It defaults to medium, or high with a logo.
+	Level      string
+	Foreground color.NRGBA
+	Background color.NRGBA
+	// Logo places the configured logo in the middle of the code.
+	Logo bool
+}
+
+// DefaultOptions returns a black on white PNG code of DefaultSize.
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       DefaultSize,
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// Generator renders QR codes, optionally with a logo loaded once at start.
+type Generator struct {
+	logo    image.Image
+	logoURI string
+}
+
+// NewGenerator creates a generator. logoPath names a PNG, JPEG or GIF
+// file to place in codes that ask for a logo; it may be empty.
+func NewGenerator(logoPath string) (*Generator, error) {
+	g := &Generator{}
+	if logoPath == "" {
+		return g, nil
+	}
+
+	file, err := os.Open(logoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open QR logo %s: %w", logoPath, err)
+	}
+	defer file.Close()
+
+	logo, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode QR logo %s: %w", logoPath, err)
+	}
+
+	// SVG codes embed the logo as a PNG data URI.
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		return nil, fmt.Errorf("failed to encode QR logo %s: %w", logoPath, err)
+	}
+
+	g.logo = logo
+	g.logoURI = "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return g, nil
+}
+
+// Render encodes content as a QR code image.
+func (g *Generator) Render(content string, opts Options) ([]byte, error) {
+	if opts.Format == "" {
+		opts.Format = FormatPNG
+	}
+	if opts.Format != FormatPNG && opts.Format != FormatSVG {
+		return nil, domain.NewValidationError("format", "format must be one of: png, svg")
+	}
+	if opts.Size == 0 {
+		opts.Size = DefaultSize
+	}
+	if opts.Size < MinSize || opts.Size > MaxSize {
+		return nil, domain.NewValidationError("size", fmt.Sprintf("size must be between %d and %d", MinSize, MaxSize))
+	}
+	margin := DefaultMargin
+	if opts.Margin != nil {
+		margin = *opts.Margin
+	}
+	if margin < 0 || margin > MaxMargin {
+		return nil, domain.NewValidationError("margin", fmt.Sprintf("margin must be between 0 and %d", MaxMargin))
+	}
+	if opts.Logo && g.logo == nil {
+		return nil, domain.NewValidationError("logo", "no QR logo is configured on this server")
+	}
+
+	levelName := opts.Level
+	if levelName == "" {
+		levelName = "medium"
+		if opts.Logo {
+			levelName = "high"
+		}
+	}
+	level, ok := levels[levelName]
+	if !ok {
+		return nil, domain.NewValidationError("level", "level must be one of: low, medium, quartile, high")
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	if opts.Format == FormatSVG {
+		return g.renderSVG(modules, margin, opts), nil
+	}
+	return g.renderPNG(modules, margin, opts)
+}
+
+func (g *Generator) renderPNG(modules [][]bool, margin int, opts Options) ([]byte, error) {
+	n := len(modules)
+	total := n + 2*margin
+	scale := opts.Size / total
+	if scale < 1 {
+		scale = 1
+	}
+	size := opts.Size
+	if size < total*scale {
+		size = total * scale
+	}
+	offset := (size - n*scale) / 2
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	fg := image.NewUniform(opts.Foreground)
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				r := image.Rect(offset+x*scale, offset+y*scale, offset+(x+1)*scale, offset+(y+1)*scale)
+				draw.Draw(img, r, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if opts.Logo {
+		box := n * scale / logoFraction
+		pad := scale
+		center := size / 2
+		backing := image.Rect(center-box/2-pad, center-box/2-pad, center+box/2+pad, center+box/2+pad)
+		draw.Draw(img, backing, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+
+		logo := fitImage(g.logo, box)
+		b := logo.Bounds()
+		at := image.Pt(center-b.Dx()/2, center-b.Dy()/2)
+		draw.Draw(img, b.Add(at), logo, image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *Generator) renderSVG(modules [][]bool, margin int, opts Options) []byte {
+	n := len(modules)
+	total := n + 2*margin
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d"%s/>`, total, total, svgFill(opts.Background))
+
+	// One subpath per horizontal run of dark modules keeps the path short.
+	fmt.Fprintf(&b, `<path%s d="`, svgFill(opts.Foreground))
+	for y, row := range modules {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < len(row) && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x+margin, y+margin, run, run)
+			x += run
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if opts.Logo {
+		box := float64(n) / logoFraction
+		pad := 1.0
+		origin := float64(total)/2 - box/2
+		fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s"%s/>`,
+			svgNum(origin-pad), svgNum(origin-pad), svgNum(box+2*pad), svgNum(box+2*pad), svgFill(opts.Background))
+		fmt.Fprintf(&b, `<image href="%s" x="%s" y="%s" width="%s" height="%s" preserveAspectRatio="xMidYMid meet"/>`,
+			g.logoURI, svgNum(origin), svgNum(origin), svgNum(box), svgNum(box))
+	}
+
+	b.WriteString("</svg>\n")
+	return []byte(b.String())
+}
+
+// svgFill returns fill attributes for c, with an opacity when it is not
+// fully opaque.
+func svgFill(c color.NRGBA) string {
+	fill := fmt.Sprintf(` fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%s"`, strconv.FormatFloat(float64(c.A)/0xff, 'f', 3, 64))
+	}
+	return fill
+}
+
+func svgNum(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// fitImage scales src to fit a box of side pixels, keeping its aspect
+// ratio. Each destination pixel averages the source pixels it covers, so
+// large logos shrink without aliasing.
+func fitImage(src image.Image, side int) *image.NRGBA {
+	sb := src.Bounds()
+	w, h := side, side
+	if sb.Dx() > sb.Dy() {
+		h = side * sb.Dy() / sb.Dx()
+	} else if sb.Dy() > sb.Dx() {
+		w = side * sb.Dx() / sb.Dy()
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := sb.Min.Y + y*sb.Dy()/h
+		y1 := sb.Min.Y + (y+1)*sb.Dy()/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0 := sb.Min.X + x*sb.Dx()/w
+			x1 := sb.Min.X + (x+1)*sb.Dx()/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			// Average premultiplied values so transparent pixels do not
+			// darken the edges.
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count), G: uint16(g / count), B: uint16(b / count), A: uint16(a / count),
+			})
+		}
+	}
+	return dst
+}
+
+// ParseColor parses a hex colour such as "#1a2b3c", "1a2b3c", "#abc" or,
+// with alpha, "#1a2b3c80".
+func ParseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q", s)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
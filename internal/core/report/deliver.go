@@ -0,0 +1,107 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// deliver sends a rendered report to its webhook or writes it to a file,
+// returning the URL or path it went to.
+func (s *Service) deliver(ctx context.Context, report *domain.Report, from time.Time, doc []byte) (string, error) {
+	if report.Delivery == domain.ReportDeliveryWebhook {
+		return report.WebhookURL, s.post(ctx, report, doc)
+	}
+	return s.write(report, from, doc)
+}
+
+// post sends doc to the report's webhook. Any 2xx response counts as
+// delivered.
+func (s *Service) post(ctx context.Context, report *domain.Report, doc []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, report.WebhookURL, bytes.NewReader(doc))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", report.Format.ContentType())
+	req.Header.Set("User-Agent", "Trelay-Reports/1.0")
+	req.Header.Set("X-Trelay-Report", strconv.FormatInt(report.ID, 10))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// write stores doc as <id>-<name>-<period start>.<ext> in the report's
+// directory. The file is written under a temporary name and renamed, so
+// readers never see a partial report.
+func (s *Service) write(report *domain.Report, from time.Time, doc []byte) (string, error) {
+	if s.dir == "" {
+		return "", fmt.Errorf("file delivery needs REPORT_DIR to be set")
+	}
+
+	dir := filepath.Join(s.dir, report.Directory)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s-%s.%s", report.ID, fileName(report.Name), from.Format("2006-01-02"), report.Format.Extension())
+	path := filepath.Join(dir, name)
+
+	tmp, err := os.CreateTemp(dir, "."+name+".*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(doc); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return path, nil
+}
+
+// fileName reduces a report name to lower-case letters, digits and dashes.
+func fileName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	if s := strings.TrimSuffix(b.String(), "-"); s != "" {
+		return s
+	}
+	return "report"
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// table is one breakdown of a report, rendered as a CSV section or a
+// Markdown table.
+type table struct {
+	title  string
+	header []string
+	rows   [][]string
+}
+
+// Render encodes report data as a document in format.
+func Render(data *domain.ReportData, format domain.ReportFormat) ([]byte, error) {
+	switch format {
+	case domain.ReportJSON:
+		doc, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode report: %w", err)
+		}
+		return append(doc, '\n'), nil
+	case domain.ReportCSV:
+		return renderCSV(data)
+	case domain.ReportMarkdown:
+		return renderMarkdown(data), nil
+	default:
+		return nil, fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+func renderCSV(data *domain.ReportData) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	writer.Write([]string{"metric", "value"})
+	writer.Write([]string{"report", data.Report})
+	writer.Write([]string{"scope", string(data.Scope)})
+	if data.Target != "" {
+		writer.Write([]string{"target", data.Target})
+	}
+	writer.Write([]string{"from", data.From.Format(time.RFC3339)})
+	writer.Write([]string{"to", data.To.Format(time.RFC3339)})
+	if data.TotalClicks != nil {
+		writer.Write([]string{"total_clicks", strconv.FormatInt(*data.TotalClicks, 10)})
+	}
+	if data.UniqueVisitors != nil {
+		writer.Write([]string{"unique_visitors", strconv.FormatInt(*data.UniqueVisitors, 10)})
+	}
+
+	for _, t := range tables(data) {
+		writer.Write([]string{})
+		writer.Write(t.header)
+		for _, row := range t.rows {
+			writer.Write(row)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderMarkdown(data *domain.ReportData) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", data.Report)
+
+	scope := string(data.Scope)
+	if data.Target != "" {
+		scope += " " + data.Target
+	}
+	// Periods end at midnight, so the last day shown is the one before.
+	period := data.From.Format("2006-01-02")
+	if last := data.To.AddDate(0, 0, -1); !last.Before(data.From.AddDate(0, 0, 1)) {
+		period += " to " + last.Format("2006-01-02")
+	}
+	fmt.Fprintf(&b, "%s, %s (%s)\n\n", capitalize(scope), period, data.From.Location())
+
+	if data.TotalClicks != nil || data.UniqueVisitors != nil {
+		b.WriteString("| Metric | Value |\n| --- | ---: |\n")
+		if data.TotalClicks != nil {
+			fmt.Fprintf(&b, "| Clicks | %d |\n", *data.TotalClicks)
+		}
+		if data.UniqueVisitors != nil {
+			fmt.Fprintf(&b, "| Unique visitors | %d |\n", *data.UniqueVisitors)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, t := range tables(data) {
+		fmt.Fprintf(&b, "## %s\n\n", t.title)
+		b.WriteString("| " + strings.Join(t.header, " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(t.header)) + "\n")
+		for _, row := range t.rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = markdownEscape(cell)
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "_Generated %s_\n", data.GeneratedAt.Format("2006-01-02 15:04 MST"))
+	return []byte(b.String())
+}
+
+// tables returns the report's non-empty breakdowns in metric order.
+func tables(data *domain.ReportData) []table {
+	var out []table
+
+	if len(data.ClicksByDay) > 0 {
+		t := table{title: "Clicks by day", header: []string{"date", "clicks", "visitors"}}
+		for _, d := range data.ClicksByDay {
+			t.rows = append(t.rows, []string{d.Date, strconv.FormatInt(d.Clicks, 10), strconv.FormatInt(d.Visitors, 10)})
+		}
+		out = append(out, t)
+	}
+	if len(data.TopLinks) > 0 {
+		t := table{title: "Top links", header: []string{"link", "clicks", "visitors"}}
+		for _, l := range data.TopLinks {
+			t.rows = append(t.rows, []string{l.Slug, strconv.FormatInt(l.Clicks, 10), strconv.FormatInt(l.Visitors, 10)})
+		}
+		out = append(out, t)
+	}
+	if len(data.TopReferrers) > 0 {
+		t := table{title: "Top referrers", header: []string{"referrer", "clicks"}}
+		for _, r := range data.TopReferrers {
+			t.rows = append(t.rows, []string{r.Referrer, strconv.FormatInt(r.Clicks, 10)})
+		}
+		out = append(out, t)
+	}
+	if len(data.Channels) > 0 {
+		t := table{title: "Channels", header: []string{"channel", "clicks"}}
+		for _, c := range data.Channels {
+			t.rows = append(t.rows, []string{c.Channel, strconv.FormatInt(c.Clicks, 10)})
+		}
+		out = append(out, t)
+	}
+	if len(data.TopCountries) > 0 {
+		t := table{title: "Top countries", header: []string{"country", "clicks"}}
+		for _, c := range data.TopCountries {
+			t.rows = append(t.rows, []string{c.Country, strconv.FormatInt(c.Clicks, 10)})
+		}
+		out = append(out, t)
+	}
+	if len(data.DeviceStats) > 0 {
+		t := table{title: "Devices", header: []string{"device", "clicks"}}
+		for _, d := range data.DeviceStats {
+			t.rows = append(t.rows, []string{d.DeviceType, strconv.FormatInt(d.Clicks, 10)})
+		}
+		out = append(out, t)
+	}
+
+	return out
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// markdownEscape keeps a table cell from breaking the row.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
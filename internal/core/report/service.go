@@ -0,0 +1,436 @@
+// Package report renders scheduled stats summaries for a link, folder, tag
+// or the whole workspace and delivers them to a webhook or a directory.
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/analytics"
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/netguard"
+	"github.com/aftaab/trelay/internal/core/port"
+)
+
+const (
+	// dueBatch is how many due reports are read at a time.
+	dueBatch = 20
+	// topLimit is the length of the top lists in a report.
+	topLimit       = 10
+	webhookTimeout = 30 * time.Second
+)
+
+// Service manages report definitions and runs them.
+type Service struct {
+	repo      port.ReportRepository
+	links     port.LinkRepository
+	folders   port.FolderRepository
+	analytics *analytics.Service
+	client    *http.Client
+	// dir is where file deliveries are written; empty disables them.
+	dir string
+}
+
+// NewService creates a report service. dir is the directory reports
+// delivered as files are written under; it may be empty to allow only
+// webhook delivery.
+func NewService(repo port.ReportRepository, links port.LinkRepository, folders port.FolderRepository, analyticsService *analytics.Service, dir string) *Service {
+	return &Service{
+		repo:      repo,
+		links:     links,
+		folders:   folders,
+		analytics: analyticsService,
+		client: netguard.NewClient(netguard.ClientOptions{
+			Timeout:      webhookTimeout,
+			MaxBodyBytes: 64 * 1024,
+		}),
+		dir: dir,
+	}
+}
+
+// Create validates and stores a new report, scheduled to first run at the
+// end of the current period.
+func (s *Service) Create(ctx context.Context, req domain.CreateReportRequest) (*domain.Report, error) {
+	report := &domain.Report{
+		Name:       strings.TrimSpace(req.Name),
+		Scope:      req.Scope,
+		Cadence:    req.Cadence,
+		Timezone:   req.Timezone,
+		Metrics:    req.Metrics,
+		Format:     req.Format,
+		Delivery:   req.Delivery,
+		WebhookURL: req.WebhookURL,
+		Directory:  req.Directory,
+		Enabled:    true,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if req.Enabled != nil {
+		report.Enabled = *req.Enabled
+	}
+	if report.Timezone == "" {
+		report.Timezone = "UTC"
+	}
+	if report.Format == "" {
+		report.Format = domain.ReportMarkdown
+	}
+
+	switch req.Scope {
+	case domain.ReportScopeLink:
+		if req.Slug == "" {
+			return nil, domain.NewValidationError("slug", "slug is required for link reports")
+		}
+		link, err := s.links.GetBySlug(ctx, req.Slug)
+		if err == nil && link.IsDeleted() {
+			err = domain.ErrLinkNotFound
+		}
+		if err != nil {
+			if err == domain.ErrLinkNotFound {
+				return nil, domain.NewValidationError("slug", "link not found")
+			}
+			return nil, err
+		}
+		report.Slug = link.Slug
+	case domain.ReportScopeFolder:
+		if req.FolderID == nil {
+			return nil, domain.NewValidationError("folder_id", "folder_id is required for folder reports")
+		}
+		if _, err := s.folders.GetByID(ctx, *req.FolderID); err != nil {
+			if err == domain.ErrFolderNotFound {
+				return nil, domain.NewValidationError("folder_id", "folder not found")
+			}
+			return nil, err
+		}
+		report.FolderID = req.FolderID
+	case domain.ReportScopeTag:
+		if req.Tag == "" {
+			return nil, domain.NewValidationError("tag", "tag is required for tag reports")
+		}
+		report.Tag = req.Tag
+	case domain.ReportScopeWorkspace:
+	default:
+		return nil, domain.NewValidationError("scope", "scope must be one of: link, folder, tag, workspace")
+	}
+
+	if err := s.validate(report); err != nil {
+		return nil, err
+	}
+	report.NextRunAt = nextRun(report, time.Now())
+
+	return s.repo.Create(ctx, report)
+}
+
+// Update changes a report's schedule, contents or delivery. Changing the
+// cadence or time zone, or enabling the report, reschedules it to the end
+// of the current period.
+func (s *Service) Update(ctx context.Context, id int64, req domain.UpdateReportRequest) (*domain.Report, error) {
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reschedule := false
+	if req.Name != nil {
+		report.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Cadence != nil && *req.Cadence != report.Cadence {
+		report.Cadence = *req.Cadence
+		reschedule = true
+	}
+	if req.Timezone != nil && *req.Timezone != report.Timezone {
+		report.Timezone = *req.Timezone
+		reschedule = true
+	}
+	if req.Metrics != nil {
+		report.Metrics = req.Metrics
+	}
+	if req.Format != nil {
+		report.Format = *req.Format
+	}
+	if req.Delivery != nil {
+		report.Delivery = *req.Delivery
+	}
+	if req.WebhookURL != nil {
+		report.WebhookURL = *req.WebhookURL
+	}
+	if req.Directory != nil {
+		report.Directory = *req.Directory
+	}
+	if req.Enabled != nil {
+		if *req.Enabled && !report.Enabled {
+			reschedule = true
+		}
+		report.Enabled = *req.Enabled
+	}
+
+	if err := s.validate(report); err != nil {
+		return nil, err
+	}
+	if reschedule {
+		report.NextRunAt = nextRun(report, time.Now())
+	}
+
+	if err := s.repo.Update(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// validate checks the fields shared by new and updated reports and clears
+// delivery settings that do not apply.
+func (s *Service) validate(report *domain.Report) error {
+	if report.Name == "" {
+		return domain.NewValidationError("name", "report name is required")
+	}
+	if !report.Cadence.IsValid() {
+		return domain.NewValidationError("cadence", "cadence must be one of: daily, weekly, monthly")
+	}
+	if _, err := time.LoadLocation(report.Timezone); err != nil {
+		return domain.NewValidationError("timezone", "timezone must be an IANA time zone such as Europe/Berlin")
+	}
+	for _, m := range report.Metrics {
+		if !domain.IsReportMetric(m) {
+			return domain.NewValidationError("metrics", "metrics must be among: "+strings.Join(domain.ReportMetrics, ", "))
+		}
+	}
+	if !report.Format.IsValid() {
+		return domain.NewValidationError("format", "format must be one of: json, csv, markdown")
+	}
+
+	switch report.Delivery {
+	case domain.ReportDeliveryWebhook:
+		u, err := url.Parse(report.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return domain.NewValidationError("webhook_url", "webhook_url must be an http or https URL")
+		}
+		report.Directory = ""
+	case domain.ReportDeliveryFile:
+		if s.dir == "" {
+			return domain.NewValidationError("delivery", "file delivery needs REPORT_DIR to be set on the server")
+		}
+		if report.Directory != "" && !filepath.IsLocal(report.Directory) {
+			return domain.NewValidationError("directory", "directory must be a relative path inside the report directory")
+		}
+		report.WebhookURL = ""
+	default:
+		return domain.NewValidationError("delivery", "delivery must be one of: webhook, file")
+	}
+
+	return nil
+}
+
+func (s *Service) List(ctx context.Context) ([]*domain.Report, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*domain.Report, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Run renders and delivers a report for its last whole period now,
+// without changing when it runs next. A failed delivery is reported in
+// the run rather than as an error.
+func (s *Service) Run(ctx context.Context, id int64) (*domain.ReportRun, error) {
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	run := s.run(ctx, report, time.Now())
+	if err := s.repo.RecordRun(ctx, report.ID, run.RanAt, run.Error, nil); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// Preview renders a report for its last whole period without delivering
+// it.
+func (s *Service) Preview(ctx context.Context, id int64) (*domain.Report, []byte, error) {
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	from, to := report.Cadence.LastPeriod(time.Now(), report.Location())
+	data, err := s.gather(ctx, report, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := Render(data, report.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return report, doc, nil
+}
+
+// RunDue runs every report that is due and schedules each for the end of
+// the current period. It returns how many reports ran.
+func (s *Service) RunDue(ctx context.Context) (int, error) {
+	ran := 0
+	for {
+		now := time.Now()
+		reports, err := s.repo.ListDue(ctx, now, dueBatch)
+		if err != nil {
+			return ran, err
+		}
+
+		for _, report := range reports {
+			if ctx.Err() != nil {
+				return ran, ctx.Err()
+			}
+			run := s.run(ctx, report, now)
+			next := nextRun(report, now)
+			if err := s.repo.RecordRun(context.WithoutCancel(ctx), report.ID, run.RanAt, run.Error, &next); err != nil {
+				return ran, err
+			}
+			ran++
+		}
+
+		if len(reports) < dueBatch {
+			return ran, nil
+		}
+	}
+}
+
+// RunScheduler runs due reports once per interval until ctx is cancelled.
+// Reports missed while the server was down run once, for their last whole
+// period, on the first check.
+func (s *Service) RunScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// run renders and delivers a report for the last whole period before now.
+func (s *Service) run(ctx context.Context, report *domain.Report, now time.Time) *domain.ReportRun {
+	from, to := report.Cadence.LastPeriod(now, report.Location())
+	run := &domain.ReportRun{ReportID: report.ID, From: from, To: to, RanAt: now.UTC()}
+
+	data, err := s.gather(ctx, report, from, to)
+	if err == nil {
+		var doc []byte
+		if doc, err = Render(data, report.Format); err == nil {
+			run.Location, err = s.deliver(ctx, report, from, doc)
+		}
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	return run
+}
+
+// gather collects the report's metrics for clicks from from up to to.
+func (s *Service) gather(ctx context.Context, report *domain.Report, from, to time.Time) (*domain.ReportData, error) {
+	end := to.Add(-time.Nanosecond)
+	filter := domain.StatsFilter{
+		StartDate: &from,
+		EndDate:   &end,
+		Location:  report.Location(),
+	}
+
+	data := &domain.ReportData{
+		Report:      report.Name,
+		Scope:       report.Scope,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	var (
+		totalClicks, uniqueVisitors int64
+		days                        []domain.DayStats
+		referrers                   []domain.ReferrerStats
+		channels                    []domain.ChannelStats
+		countries                   []domain.CountryStats
+		devices                     []domain.DeviceStats
+	)
+
+	if report.Scope == domain.ReportScopeLink {
+		link, err := s.links.GetBySlug(ctx, report.Slug)
+		if err != nil {
+			return nil, err
+		}
+		data.Target = link.Slug
+
+		stats, err := s.analytics.GetStats(ctx, link.ID, filter)
+		if err != nil {
+			return nil, err
+		}
+		totalClicks, uniqueVisitors = stats.TotalClicks, stats.UniqueVisitors
+		days, referrers, channels, countries, devices = stats.ClicksByDay, stats.TopReferrers, stats.Channels, stats.TopCountries, stats.DeviceStats
+	} else {
+		switch report.Scope {
+		case domain.ReportScopeFolder:
+			filter.FolderID = report.FolderID
+			data.Target = strconv.FormatInt(*report.FolderID, 10)
+			if folder, err := s.folders.GetByID(ctx, *report.FolderID); err == nil {
+				data.Target = folder.Name
+			}
+		case domain.ReportScopeTag:
+			filter.Tag = report.Tag
+			data.Target = report.Tag
+		}
+
+		stats, err := s.analytics.GetWorkspaceStats(ctx, int(to.Sub(from).Hours()/24)+1, topLimit, filter)
+		if err != nil {
+			return nil, err
+		}
+		totalClicks, uniqueVisitors = stats.TotalClicks, stats.UniqueVisitors
+		days, referrers, channels, countries, devices = stats.ClicksByDay, stats.TopReferrers, stats.Channels, stats.TopCountries, stats.DeviceStats
+		if report.HasMetric(domain.ReportMetricTopLinks) {
+			data.TopLinks = stats.TopLinks
+		}
+	}
+
+	if report.HasMetric(domain.ReportMetricClicks) {
+		data.TotalClicks = &totalClicks
+	}
+	if report.HasMetric(domain.ReportMetricVisitors) {
+		data.UniqueVisitors = &uniqueVisitors
+	}
+	if report.HasMetric(domain.ReportMetricDaily) {
+		data.ClicksByDay = days
+	}
+	if report.HasMetric(domain.ReportMetricReferrers) {
+		data.TopReferrers = referrers
+	}
+	if report.HasMetric(domain.ReportMetricChannels) {
+		data.Channels = channels
+	}
+	if report.HasMetric(domain.ReportMetricCountries) {
+		data.TopCountries = countries
+	}
+	if report.HasMetric(domain.ReportMetricDevices) {
+		data.DeviceStats = devices
+	}
+
+	return data, nil
+}
+
+// nextRun returns when a report runs next: at the end of the period
+// containing now.
+func nextRun(report *domain.Report, now time.Time) time.Time {
+	start := report.Cadence.PeriodStart(now, report.Location())
+	return report.Cadence.Step(start, 1).UTC()
+}
@@ -0,0 +1,158 @@
+package url
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// Blocklist is a reloadable set of blocked domains and URL prefixes loaded
+// from local files. Each file may be in hosts-file format
+// ("0.0.0.0 bad.example") or a plain list of domains ("bad.example",
+// "*.bad.example") and URLs ("https://example.com/phish").
+type Blocklist struct {
+	paths []string
+
+	mu       sync.RWMutex
+	domains  map[string]bool
+	prefixes []string
+}
+
+// NewBlocklist loads the given files. Missing files are an error.
+func NewBlocklist(paths ...string) (*Blocklist, error) {
+	b := &Blocklist{paths: paths, domains: make(map[string]bool)}
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Paths returns the files backing this blocklist.
+func (b *Blocklist) Paths() []string {
+	return b.paths
+}
+
+// Len returns the number of loaded domain and URL entries.
+func (b *Blocklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.domains) + len(b.prefixes)
+}
+
+// Reload re-reads all files. On error the previous entries are kept.
+func (b *Blocklist) Reload() error {
+	domains := make(map[string]bool)
+	var prefixes []string
+
+	for _, path := range b.paths {
+		if err := loadBlocklistFile(path, domains, &prefixes); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	b.domains = domains
+	b.prefixes = prefixes
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Screen implements Stage.
+func (b *Blocklist) Screen(ctx context.Context, u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	target := host + u.EscapedPath()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	// Match the host and every parent domain so entries cover subdomains.
+	for h := host; h != ""; {
+		if b.domains[h] {
+			return domain.NewValidationError("url", "destination domain is blocklisted")
+		}
+		idx := strings.Index(h, ".")
+		if idx == -1 {
+			break
+		}
+		h = h[idx+1:]
+	}
+
+	for _, prefix := range b.prefixes {
+		if strings.HasPrefix(target, prefix) {
+			return domain.NewValidationError("url", "destination URL is blocklisted")
+		}
+	}
+
+	return nil
+}
+
+func loadBlocklistFile(path string, domains map[string]bool, prefixes *[]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open blocklist %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		// Hosts-file format: "<ip> host [host...]"
+		if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			for _, host := range fields[1:] {
+				addBlockedDomain(domains, host)
+			}
+			continue
+		}
+
+		entry := fields[0]
+		if strings.Contains(entry, "://") {
+			parsed, err := url.Parse(entry)
+			if err != nil || parsed.Hostname() == "" {
+				continue
+			}
+			host := strings.ToLower(parsed.Hostname())
+			if parsed.Path == "" || parsed.Path == "/" {
+				addBlockedDomain(domains, host)
+				continue
+			}
+			*prefixes = append(*prefixes, host+parsed.EscapedPath())
+			continue
+		}
+
+		addBlockedDomain(domains, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read blocklist %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func addBlockedDomain(domains map[string]bool, host string) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimPrefix(host, "*.")
+	host = strings.TrimSuffix(host, ".")
+	switch host {
+	case "", "localhost", "localhost.localdomain", "local", "broadcasthost", "0.0.0.0":
+		return
+	}
+	domains[host] = true
+}
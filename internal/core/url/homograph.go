@@ -0,0 +1,113 @@
+package url
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// confusableScripts are scripts with letters that render like Latin ones.
+var confusableScripts = map[string]*unicode.RangeTable{
+	"Cyrillic": unicode.Cyrillic,
+	"Greek":    unicode.Greek,
+	"Armenian": unicode.Armenian,
+	"Cherokee": unicode.Cherokee,
+}
+
+// latinLookalikes are non-Latin letters that are visually identical or near
+// identical to Latin letters. A label made only of these is a whole-script
+// spoof (e.g. Cyrillic "аррӏе" for "apple").
+var latinLookalikes = map[rune]bool{
+	// Cyrillic
+	'а': true, 'в': true, 'е': true, 'к': true, 'м': true, 'н': true, 'о': true,
+	'р': true, 'с': true, 'т': true, 'у': true, 'х': true, 'ѕ': true, 'і': true,
+	'ј': true, 'һ': true, 'ӏ': true, 'ԁ': true, 'ԛ': true, 'ԝ': true, 'ɡ': true,
+	// Greek
+	'α': true, 'ι': true, 'κ': true, 'ν': true, 'ο': true, 'ρ': true, 'τ': true,
+	'υ': true, 'χ': true,
+	// Armenian
+	'օ': true, 'ս': true, 'ց': true, 'հ': true, 'ո': true,
+}
+
+// HomographStage flags internationalized domain names that mix Latin with
+// lookalike scripts, or consist entirely of Latin lookalike characters.
+type HomographStage struct{}
+
+// NewHomographStage creates a punycode homograph heuristic stage.
+func NewHomographStage() *HomographStage {
+	return &HomographStage{}
+}
+
+// Screen implements Stage.
+func (h *HomographStage) Screen(ctx context.Context, u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+	if !strings.Contains(host, "xn--") && isASCII(host) {
+		return nil
+	}
+
+	unicodeHost, err := idna.Lookup.ToUnicode(host)
+	if err != nil {
+		return domain.NewValidationError("url", "destination has an invalid internationalized domain name")
+	}
+
+	for _, label := range strings.Split(unicodeHost, ".") {
+		if isASCII(label) {
+			continue
+		}
+		if mixesConfusableScripts(label) || isWholeScriptLookalike(label) {
+			return domain.NewValidationError("url", "destination domain looks like a homograph of another domain")
+		}
+	}
+
+	return nil
+}
+
+func mixesConfusableScripts(label string) bool {
+	scripts := make(map[string]bool)
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.Is(unicode.Latin, r) {
+			scripts["Latin"] = true
+			continue
+		}
+		for name, table := range confusableScripts {
+			if unicode.Is(table, r) {
+				scripts[name] = true
+				break
+			}
+		}
+	}
+
+	// Any combination of Latin and/or lookalike scripts in one label is suspect.
+	return len(scripts) > 1
+}
+
+func isWholeScriptLookalike(label string) bool {
+	letters := 0
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if !latinLookalikes[r] {
+			return false
+		}
+		letters++
+	}
+	return letters > 0
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
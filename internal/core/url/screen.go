@@ -0,0 +1,187 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/netguard"
+)
+
+const (
+	DefaultScreenCacheTTL = 10 * time.Minute
+	maxScreenCacheEntries = 10000
+	resolveTimeout        = 3 * time.Second
+)
+
+// errScreenInconclusive marks a stage result that should not be cached
+// (e.g. a transient DNS failure). The destination is allowed.
+var errScreenInconclusive = errors.New("screening inconclusive")
+
+// Stage is a single destination safety check. Screen returns a
+// domain.ValidationError when the destination must be rejected.
+type Stage interface {
+	Screen(ctx context.Context, u *url.URL) error
+}
+
+// Screener runs destination URLs through a chain of stages and caches the
+// verdict per URL.
+type Screener struct {
+	stages []Stage
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]screenEntry
+}
+
+type screenEntry struct {
+	err     error
+	expires time.Time
+}
+
+// NewScreener creates a screener that runs stages in order.
+func NewScreener(ttl time.Duration, stages ...Stage) *Screener {
+	if ttl <= 0 {
+		ttl = DefaultScreenCacheTTL
+	}
+	return &Screener{
+		stages: stages,
+		ttl:    ttl,
+		cache:  make(map[string]screenEntry),
+	}
+}
+
+// Screen checks a normalized destination URL against all stages.
+func (s *Screener) Screen(ctx context.Context, rawURL string) error {
+	if s == nil || len(s.stages) == 0 {
+		return nil
+	}
+
+	if entry, ok := s.cached(rawURL); ok {
+		return entry.err
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return domain.ErrURLInvalid
+	}
+
+	var verdict error
+	inconclusive := false
+	for _, stage := range s.stages {
+		if err := stage.Screen(ctx, parsed); err != nil {
+			if errors.Is(err, errScreenInconclusive) {
+				inconclusive = true
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			verdict = err
+			break
+		}
+	}
+
+	// A rejection stands, but an allow that skipped a stage is only good
+	// for this call.
+	if verdict != nil || !inconclusive {
+		s.store(rawURL, verdict)
+	}
+	return verdict
+}
+
+// Purge drops all cached verdicts, e.g. after a blocklist reload.
+func (s *Screener) Purge() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]screenEntry)
+}
+
+func (s *Screener) cached(rawURL string) (screenEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[rawURL]
+	if !ok {
+		return screenEntry{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.cache, rawURL)
+		return screenEntry{}, false
+	}
+	return entry, true
+}
+
+func (s *Screener) store(rawURL string, verdict error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.cache) >= maxScreenCacheEntries {
+		for key, entry := range s.cache {
+			if now.After(entry.expires) {
+				delete(s.cache, key)
+			}
+		}
+		if len(s.cache) >= maxScreenCacheEntries {
+			s.cache = make(map[string]screenEntry)
+		}
+	}
+
+	s.cache[rawURL] = screenEntry{err: verdict, expires: now.Add(s.ttl)}
+}
+
+// AddressStage resolves the destination host and rejects private,
+// loopback, link-local and cloud metadata addresses.
+type AddressStage struct {
+	resolver *net.Resolver
+}
+
+// NewAddressStage creates an address stage. A nil resolver uses the system default.
+func NewAddressStage(resolver *net.Resolver) *AddressStage {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &AddressStage{resolver: resolver}
+}
+
+// Screen implements Stage.
+func (a *AddressStage) Screen(ctx context.Context, u *url.URL) error {
+	host := strings.TrimSuffix(u.Hostname(), ".")
+	if host == "" {
+		return domain.ErrURLInvalid
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if netguard.IsBlockedAddr(addr) {
+			return domain.NewValidationError("url", "destination points to a private or reserved address")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	addrs, err := a.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		// Unresolvable hosts are not our concern here; fetches are guarded at dial time.
+		return errScreenInconclusive
+	}
+
+	for _, addr := range addrs {
+		if netguard.IsBlockedAddr(addr) {
+			return domain.NewValidationError("url", "destination resolves to a private or reserved address")
+		}
+	}
+
+	return nil
+}
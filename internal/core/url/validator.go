@@ -2,12 +2,19 @@ package url
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/netguard"
 )
 
 const (
@@ -34,6 +41,7 @@ type Validator struct {
 	maxLength    int
 	blockedHosts map[string]bool
 	selfDomains  []string
+	client       *http.Client
 }
 
 // NewValidator creates a new URL validator.
@@ -55,6 +63,10 @@ func NewValidator(maxLength int, selfDomains []string) *Validator {
 		maxLength:    maxLength,
 		blockedHosts: blocked,
 		selfDomains:  selfDomains,
+		client: netguard.NewClient(netguard.ClientOptions{
+			Timeout:      ReachableTimeout,
+			MaxRedirects: 10,
+		}),
 	}
 }
 
@@ -113,36 +125,85 @@ func (v *Validator) Normalize(rawURL string) (string, error) {
 
 // CheckReachable attempts to reach the URL (optional validation).
 func (v *Validator) CheckReachable(ctx context.Context, rawURL string) error {
+	result, err := v.Probe(ctx, rawURL)
+	if err != nil {
+		if errors.Is(err, domain.ErrURLUnreachable) {
+			return domain.ErrURLUnreachable
+		}
+		return err
+	}
+
+	// Accept 2xx, 3xx, and some 4xx status codes
+	if result.StatusCode >= 500 {
+		return domain.ErrURLUnreachable
+	}
+
+	return nil
+}
+
+// ProbeResult describes the response received when probing a destination.
+type ProbeResult struct {
+	StatusCode int
+	// FinalURL is the URL that answered after following redirects.
+	FinalURL string
+}
+
+// Probe requests the URL through the hardened client, following redirects,
+// and reports the final status code and URL. HEAD is tried first and GET is
+// used when the server does not support HEAD. Network failures are returned
+// wrapped in domain.ErrURLUnreachable.
+func (v *Validator) Probe(ctx context.Context, rawURL string) (*ProbeResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, ReachableTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
-	if err != nil {
-		return domain.ErrURLInvalid
+	result, err := v.probe(ctx, http.MethodHead, rawURL)
+	if err == nil && (result.StatusCode == http.StatusMethodNotAllowed || result.StatusCode == http.StatusNotImplemented) {
+		result, err = v.probe(ctx, http.MethodGet, rawURL)
 	}
+	return result, err
+}
 
-	client := &http.Client{
-		Timeout: ReachableTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 10 {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
+func (v *Validator) probe(ctx context.Context, method, rawURL string) (*ProbeResult, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, domain.ErrURLInvalid
 	}
+	req.Header.Set("User-Agent", "Trelay/1.0 (Link Checker)")
 
-	resp, err := client.Do(req)
+	resp, err := v.client.Do(req)
 	if err != nil {
-		return domain.ErrURLUnreachable
+		if netguard.IsBlocked(err) {
+			return nil, domain.NewValidationError("url", "destination address is not allowed")
+		}
+		return nil, fmt.Errorf("%w: %s", domain.ErrURLUnreachable, probeErrorReason(err))
 	}
 	defer resp.Body.Close()
 
-	// Accept 2xx, 3xx, and some 4xx status codes
-	if resp.StatusCode >= 500 {
-		return domain.ErrURLUnreachable
-	}
+	return &ProbeResult{
+		StatusCode: resp.StatusCode,
+		FinalURL:   resp.Request.URL.String(),
+	}, nil
+}
 
-	return nil
+// probeErrorReason condenses a client error into a short, stable description.
+func probeErrorReason(err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err):
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns lookup failed"
+	case errors.Is(err, netguard.ErrTooManyRedirects):
+		return "too many redirects"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	default:
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return "tls certificate invalid"
+		}
+		return "connection failed"
+	}
 }
 
 // ExtractHost extracts the hostname from a URL.
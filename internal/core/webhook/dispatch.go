@@ -0,0 +1,248 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+const (
+	// deliveryBatch is how many due deliveries are read at a time.
+	deliveryBatch = 50
+	// retryBase is the wait after the first failed attempt; it doubles
+	// with each further failure up to retryMax.
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+	// pruneInterval is how often finished deliveries past the log
+	// retention are deleted.
+	pruneInterval = time.Hour
+)
+
+// Sign returns the hex HMAC-SHA256 of timestamp, a dot and body, keyed with
+// secret. Receivers recompute it to check the X-Trelay-Signature header.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RunDispatcher delivers queued events until ctx is cancelled. It checks
+// the queue once per interval and whenever an event is queued, and prunes
+// the delivery log. Deliveries interrupted by a shutdown are retried after
+// restart, so receivers may see an event more than once.
+func (s *Service) RunDispatcher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.DeliverDue(ctx)
+			s.prune(ctx)
+		case <-s.wake:
+			s.DeliverDue(ctx)
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is due and returns how many were
+// attempted.
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	attempted := 0
+	for {
+		deliveries, err := s.repo.ListDueDeliveries(ctx, time.Now(), deliveryBatch)
+		if err != nil {
+			return attempted, err
+		}
+
+		sem := make(chan struct{}, s.opts.Concurrency)
+		errs := make(chan error, len(deliveries))
+		var wg sync.WaitGroup
+
+		for _, d := range deliveries {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return attempted, ctx.Err()
+			case sem <- struct{}{}:
+			}
+
+			wg.Add(1)
+			go func(d *domain.WebhookDelivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if err := s.attempt(ctx, d); err != nil {
+					errs <- err
+				}
+			}(d)
+			attempted++
+		}
+		wg.Wait()
+		close(errs)
+
+		// A delivery whose outcome could not be stored is still due, so
+		// carrying on would pick it straight back up.
+		if err := <-errs; err != nil {
+			return attempted, err
+		}
+		if len(deliveries) < deliveryBatch {
+			return attempted, nil
+		}
+	}
+}
+
+// attempt sends a queued delivery once and stores the outcome, scheduling
+// a retry with exponential backoff until the attempts run out.
+func (s *Service) attempt(ctx context.Context, d *domain.WebhookDelivery) error {
+	hook := s.webhook(d.WebhookID)
+	if hook == nil {
+		var err error
+		if hook, err = s.repo.GetByID(ctx, d.WebhookID); err != nil {
+			return err
+		}
+	}
+
+	status, err := s.send(ctx, hook, d)
+	if ctx.Err() != nil {
+		// Shutting down; leave the delivery due for the next start.
+		return nil
+	}
+
+	now := time.Now().UTC()
+	d.Attempts++
+	d.ResponseStatus = status
+	d.Error = ""
+	switch {
+	case err == nil:
+		d.Status = domain.DeliveryDelivered
+		d.CompletedAt = &now
+	case d.Attempts >= s.opts.MaxAttempts:
+		d.Status = domain.DeliveryFailed
+		d.Error = err.Error()
+		d.CompletedAt = &now
+	default:
+		d.Error = err.Error()
+		d.NextAttemptAt = now.Add(backoff(d.Attempts))
+	}
+
+	return s.repo.UpdateDelivery(context.WithoutCancel(ctx), d)
+}
+
+// send POSTs a delivery's payload to hook, signed with its secret, and
+// returns the response status. Any 2xx response counts as delivered.
+func (s *Service) send(ctx context.Context, hook *domain.Webhook, d *domain.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Trelay-Webhooks/1.0")
+	req.Header.Set("X-Trelay-Event", string(d.Event))
+	req.Header.Set("X-Trelay-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Trelay-Timestamp", timestamp)
+	req.Header.Set("X-Trelay-Signature", "sha256="+Sign(hook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns how long to wait after the given number of failed
+// attempts.
+func backoff(attempts int) time.Duration {
+	wait := retryBase
+	for i := 1; i < attempts && wait < retryMax; i++ {
+		wait *= 2
+	}
+	if wait > retryMax {
+		wait = retryMax
+	}
+	return wait
+}
+
+// Test sends a webhook.test event to a webhook now, whether or not it is
+// enabled, and returns the logged delivery. A failed test is not retried.
+func (s *Service) Test(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	hook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	body, err := encodePayload(domain.EventWebhookTest, now, map[string]int64{"webhook_id": hook.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	d := &domain.WebhookDelivery{
+		WebhookID: hook.ID,
+		Event:     domain.EventWebhookTest,
+		Payload:   string(body),
+		Status:    domain.DeliveryPending,
+		// Keeps the dispatcher off the delivery while it is being sent.
+		NextAttemptAt: now.Add(s.opts.Timeout + retryBase),
+		CreatedAt:     now,
+	}
+	if err := s.repo.Enqueue(ctx, []*domain.WebhookDelivery{d}); err != nil {
+		return nil, err
+	}
+	if d.ID == 0 {
+		return nil, domain.ErrWebhookNotFound
+	}
+
+	status, err := s.send(ctx, hook, d)
+	completed := time.Now().UTC()
+	d.Attempts = 1
+	d.ResponseStatus = status
+	d.CompletedAt = &completed
+	d.Status = domain.DeliveryDelivered
+	if err != nil {
+		d.Status = domain.DeliveryFailed
+		d.Error = err.Error()
+	}
+
+	if err := s.repo.UpdateDelivery(context.WithoutCancel(ctx), d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// prune deletes finished deliveries older than the log retention, at most
+// once per pruneInterval.
+func (s *Service) prune(ctx context.Context) {
+	if time.Since(s.lastPrune) < pruneInterval {
+		return
+	}
+	if _, err := s.repo.PruneDeliveries(ctx, time.Now().Add(-s.opts.LogRetention)); err == nil {
+		s.lastPrune = time.Now()
+	}
+}
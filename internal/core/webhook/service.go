@@ -0,0 +1,401 @@
+// Package webhook manages outbound webhook subscriptions and delivers
+// signed link and click event payloads to them from a durable queue.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/event"
+	"github.com/aftaab/trelay/internal/core/netguard"
+	"github.com/aftaab/trelay/internal/core/port"
+)
+
+const (
+	secretLength = 32
+	secretPrefix = "whsec_"
+
+	defaultMaxAttempts  = 8
+	defaultConcurrency  = 4
+	defaultTimeout      = 10 * time.Second
+	defaultLogRetention = 7 * 24 * time.Hour
+
+	// maxDeliveryLog caps how many deliveries one log request returns.
+	maxDeliveryLog = 200
+)
+
+// Options tunes delivery. Zero values use the defaults.
+type Options struct {
+	// MaxAttempts is how many times a delivery is tried before it fails.
+	MaxAttempts int
+	// Concurrency bounds how many deliveries are sent at once.
+	Concurrency int
+	// Timeout bounds each delivery request.
+	Timeout time.Duration
+	// LogRetention is how long finished deliveries are kept in the log.
+	LogRetention time.Duration
+}
+
+// Service manages webhooks and queues events for them.
+type Service struct {
+	repo   port.WebhookRepository
+	client *http.Client
+	opts   Options
+
+	// hooks caches the enabled webhooks so notify can skip events nobody
+	// subscribes to without a query. It is reloaded on every change.
+	mu     sync.RWMutex
+	hooks  []*domain.Webhook
+	loaded bool
+
+	// wake prompts the dispatcher to deliver newly queued events without
+	// waiting for its next tick.
+	wake chan struct{}
+
+	lastPrune time.Time
+}
+
+// NewService creates a webhook service. Events reach it once it is
+// subscribed to an event bus.
+func NewService(repo port.WebhookRepository, opts Options) *Service {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.LogRetention <= 0 {
+		opts.LogRetention = defaultLogRetention
+	}
+
+	client := netguard.NewClient(netguard.ClientOptions{
+		Timeout:      opts.Timeout,
+		MaxBodyBytes: 64 * 1024,
+	})
+	// A redirected POST would be resent as a GET without the payload, so a
+	// 3xx response is a failed attempt rather than followed.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &Service{
+		repo:   repo,
+		client: client,
+		opts:   opts,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Create validates and stores a new webhook. The response carries the
+// secret, which is not returned again.
+func (s *Service) Create(ctx context.Context, req domain.CreateWebhookRequest) (*domain.Webhook, error) {
+	now := time.Now().UTC()
+	webhook := &domain.Webhook{
+		URL:       strings.TrimSpace(req.URL),
+		Secret:    req.Secret,
+		Events:    req.Events,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = generateSecret()
+	}
+
+	if err := validate(webhook); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.Create(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	s.reload(ctx)
+	return created, nil
+}
+
+// Update changes a webhook. The secret is only returned if it changed.
+func (s *Service) Update(ctx context.Context, id int64, req domain.UpdateWebhookRequest) (*domain.Webhook, error) {
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secretChanged := false
+	if req.URL != nil {
+		webhook.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.RotateSecret {
+		webhook.Secret = generateSecret()
+		secretChanged = true
+	} else if req.Secret != nil {
+		webhook.Secret = *req.Secret
+		secretChanged = true
+	}
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	webhook.UpdatedAt = time.Now().UTC()
+
+	if err := validate(webhook); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+	s.reload(ctx)
+
+	if !secretChanged {
+		webhook.Secret = ""
+	}
+	return webhook, nil
+}
+
+func validate(webhook *domain.Webhook) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return domain.NewValidationError("url", "url must be an http or https URL")
+	}
+	if len(webhook.Secret) < 16 {
+		return domain.NewValidationError("secret", "secret must be at least 16 characters")
+	}
+	for _, e := range webhook.Events {
+		if !e.IsValid() {
+			names := make([]string, len(domain.WebhookEvents))
+			for i, name := range domain.WebhookEvents {
+				names[i] = string(name)
+			}
+			return domain.NewValidationError("events", "events must be among: "+strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+func (s *Service) List(ctx context.Context) ([]*domain.Webhook, error) {
+	webhooks, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range webhooks {
+		w.Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *Service) Get(ctx context.Context, id int64) (*domain.Webhook, error) {
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// Delete removes a webhook and drops its queued deliveries.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.reload(ctx)
+	return nil
+}
+
+// Deliveries returns a webhook's most recent deliveries, newest first.
+func (s *Service) Deliveries(ctx context.Context, id int64, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	switch filter.Status {
+	case "", domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryFailed:
+	default:
+		return nil, domain.NewValidationError("status", "status must be one of: pending, delivered, failed")
+	}
+	if filter.Limit <= 0 || filter.Limit > maxDeliveryLog {
+		filter.Limit = maxDeliveryLog
+	}
+	return s.repo.ListDeliveries(ctx, id, filter)
+}
+
+// Subscribe queues deliveries for the link and click events published on
+// bus. Bot clicks are left out, as they are from live click streams.
+func (s *Service) Subscribe(bus *event.Bus) {
+	event.Subscribe(bus, func(ctx context.Context, e event.LinkCreated) {
+		s.notify(ctx, domain.EventLinkCreated, e.Link)
+	})
+	event.Subscribe(bus, func(ctx context.Context, e event.LinkUpdated) {
+		s.notify(ctx, domain.EventLinkUpdated, e.Link)
+	})
+	event.Subscribe(bus, func(ctx context.Context, e event.LinkRestored) {
+		s.notify(ctx, domain.EventLinkUpdated, e.Link)
+	})
+	event.Subscribe(bus, func(ctx context.Context, e event.LinkDeleted) {
+		// Emptying the trash is not a second deletion.
+		if !e.Trashed {
+			s.notify(ctx, domain.EventLinkDeleted, e.Link)
+		}
+	})
+	event.Subscribe(bus, func(ctx context.Context, e event.LinkExpired) {
+		s.notify(ctx, domain.EventLinkExpired, e.Link)
+	})
+	event.Subscribe(bus, func(ctx context.Context, e event.LinkBurned) {
+		s.notify(ctx, domain.EventLinkBurned, e.Link)
+	})
+	// Clicks are taken a batch at a time so the click writer stores their
+	// deliveries in one transaction rather than one per click.
+	event.Subscribe(bus, func(ctx context.Context, e event.ClicksRecorded) {
+		hooks := s.subscribers(ctx, domain.EventClickRecorded)
+		if len(hooks) == 0 {
+			return
+		}
+
+		var deliveries []*domain.WebhookDelivery
+		for _, click := range e.Clicks {
+			if click.Click.Bot == "" {
+				deliveries = append(deliveries, newDeliveries(hooks, domain.EventClickRecorded, click.Live())...)
+			}
+		}
+		s.enqueue(ctx, deliveries)
+	})
+}
+
+// notify queues event for every enabled webhook subscribed to it. data is
+// encoded as the payload's data field.
+func (s *Service) notify(ctx context.Context, event domain.WebhookEvent, data interface{}) {
+	hooks := s.subscribers(ctx, event)
+	if len(hooks) == 0 {
+		return
+	}
+	s.enqueue(ctx, newDeliveries(hooks, event, data))
+}
+
+// newDeliveries returns a pending delivery of event to each of hooks, or
+// none if data cannot be encoded.
+func newDeliveries(hooks []*domain.Webhook, event domain.WebhookEvent, data interface{}) []*domain.WebhookDelivery {
+	now := time.Now().UTC()
+	body, err := encodePayload(event, now, data)
+	if err != nil {
+		return nil
+	}
+
+	deliveries := make([]*domain.WebhookDelivery, len(hooks))
+	for i, hook := range hooks {
+		deliveries[i] = &domain.WebhookDelivery{
+			WebhookID:     hook.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+	}
+	return deliveries
+}
+
+// enqueue stores deliveries in one transaction and wakes the dispatcher.
+// They are stored even if ctx is cancelled afterwards; failures to store
+// them are dropped.
+func (s *Service) enqueue(ctx context.Context, deliveries []*domain.WebhookDelivery) {
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := s.repo.Enqueue(context.WithoutCancel(ctx), deliveries); err != nil {
+		return
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// subscribers returns the enabled webhooks subscribed to event.
+func (s *Service) subscribers(ctx context.Context, event domain.WebhookEvent) []*domain.Webhook {
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+	if !loaded {
+		s.reload(ctx)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hooks []*domain.Webhook
+	for _, hook := range s.hooks {
+		if hook.Subscribes(event) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// webhook returns the cached enabled webhook with id, if any.
+func (s *Service) webhook(id int64) *domain.Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, hook := range s.hooks {
+		if hook.ID == id {
+			return hook
+		}
+	}
+	return nil
+}
+
+// reload refreshes the cache of enabled webhooks. On failure the cache is
+// left as it was and is retried on the next event.
+func (s *Service) reload(ctx context.Context) {
+	webhooks, err := s.repo.List(context.WithoutCancel(ctx))
+	if err != nil {
+		s.mu.Lock()
+		s.loaded = false
+		s.mu.Unlock()
+		return
+	}
+
+	var enabled []*domain.Webhook
+	for _, w := range webhooks {
+		if w.Enabled {
+			enabled = append(enabled, w)
+		}
+	}
+
+	s.mu.Lock()
+	s.hooks, s.loaded = enabled, true
+	s.mu.Unlock()
+}
+
+func encodePayload(event domain.WebhookEvent, at time.Time, data interface{}) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(domain.WebhookPayload{Event: event, CreatedAt: at, Data: raw})
+}
+
+func generateSecret() string {
+	bytes := make([]byte, secretLength)
+	if _, err := rand.Read(bytes); err != nil {
+		// crypto/rand does not fail on supported platforms.
+		panic(err)
+	}
+	return secretPrefix + hex.EncodeToString(bytes)
+}
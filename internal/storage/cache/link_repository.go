@@ -0,0 +1,292 @@
+// Package cache provides in-process caching decorators for repositories.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+	"github.com/aftaab/trelay/internal/core/port"
+)
+
+const (
+	// DefaultLinkCacheSize is the number of slugs kept when no size is given.
+	DefaultLinkCacheSize = 10000
+	// DefaultLinkCacheTTL bounds how long another writer's changes to the
+	// same database can go unnoticed.
+	DefaultLinkCacheTTL = time.Minute
+)
+
+// LinkRepository decorates a port.LinkRepository with an LRU cache of
+// slug lookups and coalesces click count increments into periodic batched
+// updates. Writes made through it invalidate the affected entries; methods
+// it does not override pass straight through.
+type LinkRepository struct {
+	port.LinkRepository
+
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	slugs   map[int64]string
+	gen     uint64
+	pending map[int64]int64
+}
+
+type linkEntry struct {
+	link    *domain.Link
+	expires time.Time
+}
+
+// NewLinkRepository wraps next with a cache of up to size links, each kept
+// for at most ttl.
+func NewLinkRepository(next port.LinkRepository, size int, ttl time.Duration) *LinkRepository {
+	if size <= 0 {
+		size = DefaultLinkCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultLinkCacheTTL
+	}
+	return &LinkRepository{
+		LinkRepository: next,
+		size:           size,
+		ttl:            ttl,
+		entries:        make(map[string]*list.Element),
+		order:          list.New(),
+		slugs:          make(map[int64]string),
+		pending:        make(map[int64]int64),
+	}
+}
+
+// GetBySlug returns a cached copy of the link, loading it on a miss.
+func (r *LinkRepository) GetBySlug(ctx context.Context, slug string) (*domain.Link, error) {
+	r.mu.Lock()
+	if link, ok := r.lookup(slug); ok {
+		r.mu.Unlock()
+		return link, nil
+	}
+	gen := r.gen
+	r.mu.Unlock()
+
+	link, err := r.LinkRepository.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link.ClickCount += r.pending[link.ID]
+	// A write that raced with the load may have invalidated what we read.
+	if r.gen == gen {
+		r.store(link)
+	}
+	return copyLink(link), nil
+}
+
+// GetByID loads the link and adds click increments not yet flushed.
+func (r *LinkRepository) GetByID(ctx context.Context, id int64) (*domain.Link, error) {
+	link, err := r.LinkRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	link.ClickCount += r.pending[link.ID]
+	r.mu.Unlock()
+	return link, nil
+}
+
+// List loads links and adds click increments not yet flushed.
+func (r *LinkRepository) List(ctx context.Context, filter domain.ListLinksFilter) ([]*domain.Link, error) {
+	links, err := r.LinkRepository.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	for _, link := range links {
+		link.ClickCount += r.pending[link.ID]
+	}
+	r.mu.Unlock()
+	return links, nil
+}
+
+// IncrementClickCount records the click in memory; Flush writes it.
+func (r *LinkRepository) IncrementClickCount(ctx context.Context, linkID int64) error {
+	return r.AddClickCounts(ctx, map[int64]int64{linkID: 1})
+}
+
+// AddClickCounts merges counts into the increments waiting for Flush.
+func (r *LinkRepository) AddClickCounts(ctx context.Context, counts map[int64]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, n := range counts {
+		r.pending[id] += n
+		if slug, ok := r.slugs[id]; ok {
+			if elem, ok := r.entries[slug]; ok {
+				elem.Value.(*linkEntry).link.ClickCount += n
+			}
+		}
+	}
+	return nil
+}
+
+// Flush writes pending click increments in one batch. On failure they are
+// kept for the next attempt.
+func (r *LinkRepository) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	if len(r.pending) == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	counts := r.pending
+	r.pending = make(map[int64]int64)
+	r.mu.Unlock()
+
+	if err := r.LinkRepository.AddClickCounts(ctx, counts); err != nil {
+		r.mu.Lock()
+		for id, n := range counts {
+			r.pending[id] += n
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// RunFlusher flushes pending click increments every interval until ctx is
+// done. Callers should Flush once more after the last redirect is served.
+func (r *LinkRepository) RunFlusher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = r.Flush(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+// Create stores the link; a slug can be reused once hard-deleted, so any
+// stale entry is dropped.
+func (r *LinkRepository) Create(ctx context.Context, link *domain.Link) (*domain.Link, error) {
+	created, err := r.LinkRepository.Create(ctx, link)
+	r.invalidate(0, link.Slug)
+	return created, err
+}
+
+func (r *LinkRepository) Update(ctx context.Context, link *domain.Link) error {
+	err := r.LinkRepository.Update(ctx, link)
+	r.invalidate(link.ID, link.Slug)
+	return err
+}
+
+func (r *LinkRepository) Delete(ctx context.Context, slug string) error {
+	err := r.LinkRepository.Delete(ctx, slug)
+	r.invalidate(0, slug)
+	return err
+}
+
+func (r *LinkRepository) HardDelete(ctx context.Context, slug string) error {
+	err := r.LinkRepository.HardDelete(ctx, slug)
+	r.invalidate(0, slug)
+	return err
+}
+
+func (r *LinkRepository) Restore(ctx context.Context, slug string) error {
+	err := r.LinkRepository.Restore(ctx, slug)
+	r.invalidate(0, slug)
+	return err
+}
+
+func (r *LinkRepository) Burn(ctx context.Context, linkID int64) error {
+	err := r.LinkRepository.Burn(ctx, linkID)
+	r.invalidate(linkID, "")
+	return err
+}
+
+func (r *LinkRepository) UpdatePreview(ctx context.Context, linkID int64, forURL string, preview *domain.LinkPreview) error {
+	err := r.LinkRepository.UpdatePreview(ctx, linkID, forURL, preview)
+	r.invalidate(linkID, "")
+	return err
+}
+
+func (r *LinkRepository) UpdateHealth(ctx context.Context, linkID int64, forURL string, health *domain.LinkHealth, failed bool) error {
+	err := r.LinkRepository.UpdateHealth(ctx, linkID, forURL, health, failed)
+	r.invalidate(linkID, "")
+	return err
+}
+
+// lookup returns a copy of a live cached link. r.mu must be held.
+func (r *LinkRepository) lookup(slug string) (*domain.Link, bool) {
+	elem, ok := r.entries[slug]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*linkEntry)
+	if time.Now().After(entry.expires) {
+		r.remove(elem)
+		return nil, false
+	}
+	r.order.MoveToFront(elem)
+	return copyLink(entry.link), true
+}
+
+// store caches link, evicting the least recently used entry when full.
+// r.mu must be held.
+func (r *LinkRepository) store(link *domain.Link) {
+	if elem, ok := r.entries[link.Slug]; ok {
+		r.remove(elem)
+	}
+	for r.order.Len() >= r.size {
+		r.remove(r.order.Back())
+	}
+
+	entry := &linkEntry{link: copyLink(link), expires: time.Now().Add(r.ttl)}
+	r.entries[link.Slug] = r.order.PushFront(entry)
+	r.slugs[link.ID] = link.Slug
+}
+
+// remove drops a cached entry. r.mu must be held.
+func (r *LinkRepository) remove(elem *list.Element) {
+	entry := r.order.Remove(elem).(*linkEntry)
+	delete(r.entries, entry.link.Slug)
+	if r.slugs[entry.link.ID] == entry.link.Slug {
+		delete(r.slugs, entry.link.ID)
+	}
+}
+
+// invalidate drops the entries for a link ID and a slug, either of which
+// may be zero. Loads in flight are not cached afterwards.
+func (r *LinkRepository) invalidate(id int64, slug string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gen++
+	if cached, ok := r.slugs[id]; ok && id != 0 {
+		if elem, ok := r.entries[cached]; ok {
+			r.remove(elem)
+		}
+	}
+	if elem, ok := r.entries[slug]; ok {
+		r.remove(elem)
+	}
+}
+
+// copyLink returns a copy callers may modify without touching the cache.
+func copyLink(link *domain.Link) *domain.Link {
+	c := *link
+	if link.Tags != nil {
+		c.Tags = append([]string(nil), link.Tags...)
+	}
+	return &c
+}
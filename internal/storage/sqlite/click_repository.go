@@ -3,14 +3,62 @@ package sqlite
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aftaab/trelay/internal/core/domain"
 )
 
+// clickFilterClause builds the WHERE conditions shared by click queries. A
+// zero linkID matches clicks on every link, narrowed by the filter's folder
+// and tag. Bot clicks are excluded unless the filter asks for them.
+func clickFilterClause(linkID int64, filter domain.StatsFilter) (string, []interface{}) {
+	where, args := eventFilterClause(linkID, filter)
+
+	switch filter.Bots {
+	case domain.BotsExclude:
+		where += " AND bot = ''"
+	case domain.BotsOnly:
+		where += " AND bot != ''"
+	}
+	return where, args
+}
+
+// eventFilterClause builds the link, channel and time conditions of
+// clickFilterClause, for tables that share those columns with clicks.
+func eventFilterClause(linkID int64, filter domain.StatsFilter) (string, []interface{}) {
+	conditions, args := linkScopeClause(linkID, filter)
+
+	if filter.Channel != "" {
+		conditions = append(conditions, "channel = ?")
+		args = append(args, filter.Channel)
+	}
+
+	if filter.StartDate != nil {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, *filter.StartDate)
+	}
+
+	if filter.EndDate != nil {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, *filter.EndDate)
+	}
+
+	if len(conditions) == 0 {
+		return "1 = 1", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
 // ClickRepository implements port.ClickRepository for SQLite.
 type ClickRepository struct {
 	db *DB
+
+	mu           sync.Mutex
+	pruned       time.Time
+	prunedLoaded bool
 }
 
 // NewClickRepository creates a new SQLite click repository.
@@ -18,50 +66,114 @@ func NewClickRepository(db *DB) *ClickRepository {
 	return &ClickRepository{db: db}
 }
 
-// Record stores a new click event.
-func (r *ClickRepository) Record(ctx context.Context, click *domain.Click) error {
-	query := `
-		INSERT INTO clicks (link_id, timestamp, referrer, device_hash, user_agent, ip_hash)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`
+// clickInsertColumns are the columns written for each click, in the order
+// returned by clickInsertArgs.
+const clickInsertColumns = `link_id, timestamp, referrer, referrer_host, channel, device_hash, user_agent, ip_hash,
+	country, region, city, browser, browser_version, os, os_version, device_type, visitor_id, token, bot, source`
+
+// clickRowPlaceholder is one VALUES tuple matching clickInsertColumns.
+const clickRowPlaceholder = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+// clickRowsPerInsert keeps multi-row inserts under SQLite's historical
+// 999 bound-parameter limit.
+const clickRowsPerInsert = 50
 
-	_, err := r.db.ExecContext(ctx, query,
+func clickInsertArgs(click *domain.Click) []interface{} {
+	// Tokens are unique, so clicks without one store NULL.
+	var token interface{}
+	if click.Token != "" {
+		token = click.Token
+	}
+
+	return []interface{}{
 		click.LinkID,
 		click.Timestamp,
 		click.Referrer,
+		click.ReferrerHost,
+		click.Channel,
 		click.DeviceHash,
 		click.UserAgent,
 		click.IPHash,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to record click: %w", err)
+		click.Country,
+		click.Region,
+		click.City,
+		click.Browser,
+		click.BrowserVersion,
+		click.OS,
+		click.OSVersion,
+		click.DeviceType,
+		click.VisitorID,
+		token,
+		click.Bot,
+		click.Source,
 	}
+}
 
-	return nil
+// Record stores a new click event.
+func (r *ClickRepository) Record(ctx context.Context, click *domain.Click) error {
+	return r.RecordBatch(ctx, []*domain.Click{click})
 }
 
-// GetByLinkID retrieves all clicks for a specific link.
-func (r *ClickRepository) GetByLinkID(ctx context.Context, linkID int64, filter domain.StatsFilter) ([]*domain.Click, error) {
-	query := `
-		SELECT id, link_id, timestamp, referrer, device_hash
-		FROM clicks
-		WHERE link_id = ?
-	`
-	args := []interface{}{linkID}
+// RecordBatch stores several click events and their daily rollups in one
+// transaction using multi-row inserts. Either all clicks are stored or none
+// are.
+func (r *ClickRepository) RecordBatch(ctx context.Context, clicks []*domain.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
 
-	if filter.StartDate != nil {
-		query += " AND timestamp >= ?"
-		args = append(args, *filter.StartDate)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin click batch: %w", err)
 	}
+	defer tx.Rollback()
 
-	if filter.EndDate != nil {
-		query += " AND timestamp <= ?"
-		args = append(args, *filter.EndDate)
+	if err := recordRollups(ctx, tx, clicks); err != nil {
+		return fmt.Errorf("failed to roll up click batch: %w", err)
 	}
 
-	query += " ORDER BY timestamp DESC"
+	for start := 0; start < len(clicks); start += clickRowsPerInsert {
+		end := start + clickRowsPerInsert
+		if end > len(clicks) {
+			end = len(clicks)
+		}
+		chunk := clicks[start:end]
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+		placeholders := make([]string, len(chunk))
+		var args []interface{}
+		for i, click := range chunk {
+			placeholders[i] = clickRowPlaceholder
+			args = append(args, clickInsertArgs(click)...)
+		}
+
+		query := `INSERT INTO clicks (` + clickInsertColumns + `) VALUES ` + strings.Join(placeholders, ", ")
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to record click batch: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit click batch: %w", err)
+	}
+
+	return nil
+}
+
+// GetByLinkID retrieves up to limit clicks for a link, or for every link
+// matching the filter's folder and tag when linkID is 0. Clicks are ordered
+// by ID, which follows the order they were recorded in, so afterID works as
+// a cursor that stays stable while new clicks arrive.
+func (r *ClickRepository) GetByLinkID(ctx context.Context, linkID int64, filter domain.StatsFilter, afterID int64, limit int) ([]*domain.Click, error) {
+	where, args := clickFilterClause(linkID, filter)
+	query := `
+		SELECT id, link_id, timestamp, referrer, referrer_host, channel, device_hash, country, region, city,
+			browser, browser_version, os, os_version, device_type, bot, source
+		FROM clicks
+		WHERE ` + where + ` AND id > ?
+		ORDER BY id ASC
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, afterID, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get clicks: %w", err)
 	}
@@ -75,7 +187,19 @@ func (r *ClickRepository) GetByLinkID(ctx context.Context, linkID int64, filter
 			&click.LinkID,
 			&click.Timestamp,
 			&click.Referrer,
+			&click.ReferrerHost,
+			&click.Channel,
 			&click.DeviceHash,
+			&click.Country,
+			&click.Region,
+			&click.City,
+			&click.Browser,
+			&click.BrowserVersion,
+			&click.OS,
+			&click.OSVersion,
+			&click.DeviceType,
+			&click.Bot,
+			&click.Source,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan click: %w", err)
@@ -90,52 +214,290 @@ func (r *ClickRepository) GetByLinkID(ctx context.Context, linkID int64, filter
 func (r *ClickRepository) GetStatsByLinkID(ctx context.Context, linkID int64, filter domain.StatsFilter) (*domain.ClickStats, error) {
 	stats := &domain.ClickStats{}
 
-	// Get total clicks
-	totalQuery := `SELECT COUNT(*) FROM clicks WHERE link_id = ?`
-	if err := r.db.QueryRowContext(ctx, totalQuery, linkID).Scan(&stats.TotalClicks); err != nil {
+	// Get total clicks and unique visitors; clicks recorded before visitor
+	// IDs existed have none and only count towards the total.
+	src, err := r.source(ctx, linkID, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	perLink, args := src.perLinkQuery()
+	totalQuery := `SELECT COALESCE(SUM(clicks), 0), COALESCE(SUM(visitors), 0) FROM (` + perLink + `)`
+	if err := r.db.QueryRowContext(ctx, totalQuery, args...).Scan(&stats.TotalClicks, &stats.UniqueVisitors); err != nil {
 		return nil, fmt.Errorf("failed to get total clicks: %w", err)
 	}
 
-	// Get clicks by day (last 30 days)
-	dayStats, err := r.GetClicksByDay(ctx, linkID, 30)
+	// Get clicks by day (the filter's range, or the last 30 days)
+	dayStats, err := r.GetClicksByDay(ctx, linkID, 30, filter)
 	if err != nil {
 		return nil, err
 	}
 	stats.ClicksByDay = dayStats
 
+	if filter.IsShort(time.Now()) {
+		if stats.ClicksByHour, err = r.GetClicksByHour(ctx, linkID, 48, filter); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get top referrers
-	referrerStats, err := r.GetTopReferrers(ctx, linkID, 10)
+	referrerStats, err := r.GetTopReferrers(ctx, linkID, 10, filter)
 	if err != nil {
 		return nil, err
 	}
 	stats.TopReferrers = referrerStats
 
+	// Get top locations
+	countryStats, err := r.GetTopCountries(ctx, linkID, 10, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.TopCountries = countryStats
+
+	cityStats, err := r.GetTopCities(ctx, linkID, 10, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.TopCities = cityStats
+
+	// Get client breakdowns
+	browserStats, err := r.GetTopBrowsers(ctx, linkID, 10, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.Browsers = browserStats
+
+	osStats, err := r.GetTopOS(ctx, linkID, 10, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.OSStats = osStats
+
+	deviceStats, err := r.GetDeviceTypes(ctx, linkID, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.DeviceStats = deviceStats
+
+	channelStats, err := r.GetChannels(ctx, linkID, 3, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.Channels = channelStats
+
+	sourceStats, err := r.GetSources(ctx, linkID, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.Sources = sourceStats
+
+	if err := r.conversionStats(ctx, linkID, filter, stats); err != nil {
+		return nil, err
+	}
+
+	if err := r.botStats(ctx, linkID, filter, stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetWorkspaceStats retrieves stats aggregated across every link matching
+// the filter's folder and tag, using one query per breakdown.
+func (r *ClickRepository) GetWorkspaceStats(ctx context.Context, days, limit int, filter domain.StatsFilter) (*domain.WorkspaceStats, error) {
+	stats := &domain.WorkspaceStats{}
+
+	src, err := r.source(ctx, 0, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	perLink, args := src.perLinkQuery()
+	totalQuery := `SELECT COALESCE(SUM(clicks), 0), COALESCE(SUM(visitors), 0), COUNT(*) FROM (` + perLink + `)`
+	if err := r.db.QueryRowContext(ctx, totalQuery, args...).Scan(&stats.TotalClicks, &stats.UniqueVisitors, &stats.ActiveLinks); err != nil {
+		return nil, fmt.Errorf("failed to get workspace totals: %w", err)
+	}
+
+	if stats.ClicksByDay, err = r.GetClicksByDay(ctx, 0, days, filter); err != nil {
+		return nil, err
+	}
+	if filter.IsShort(time.Now()) {
+		if stats.ClicksByHour, err = r.GetClicksByHour(ctx, 0, 48, filter); err != nil {
+			return nil, err
+		}
+	}
+	if stats.TopLinks, err = r.GetTopLinks(ctx, limit, filter); err != nil {
+		return nil, err
+	}
+	if stats.TopReferrers, err = r.GetTopReferrers(ctx, 0, limit, filter); err != nil {
+		return nil, err
+	}
+	if stats.Channels, err = r.GetChannels(ctx, 0, 3, filter); err != nil {
+		return nil, err
+	}
+	if stats.TopCountries, err = r.GetTopCountries(ctx, 0, limit, filter); err != nil {
+		return nil, err
+	}
+	if stats.DeviceStats, err = r.GetDeviceTypes(ctx, 0, filter); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
-// GetClicksByDay retrieves daily click counts for a link.
-func (r *ClickRepository) GetClicksByDay(ctx context.Context, linkID int64, days int) ([]domain.DayStats, error) {
-	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+// GetTopLinks retrieves the most clicked links matching the filter.
+func (r *ClickRepository) GetTopLinks(ctx context.Context, limit int, filter domain.StatsFilter) ([]domain.LinkClickStats, error) {
+	src, err := r.source(ctx, 0, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	perLink, args := src.perLinkQuery()
 
 	query := `
-		SELECT DATE(timestamp) as date, COUNT(*) as clicks
-		FROM clicks
-		WHERE link_id = ? AND DATE(timestamp) >= ?
-		GROUP BY DATE(timestamp)
-		ORDER BY date ASC
+		SELECT t.link_id, l.slug, t.clicks, t.visitors
+		FROM (` + perLink + ` ORDER BY clicks DESC LIMIT ?) t
+		JOIN links l ON l.id = t.link_id
+		ORDER BY t.clicks DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, linkID, startDate)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top links: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.LinkClickStats
+	for rows.Next() {
+		var s domain.LinkClickStats
+		if err := rows.Scan(&s.LinkID, &s.Slug, &s.Clicks, &s.Visitors); err != nil {
+			return nil, fmt.Errorf("failed to scan link stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetClicksByHour retrieves hourly click counts for a link, or for every
+// link when linkID is 0, covering the filter's range or else the last hours.
+func (r *ClickRepository) GetClicksByHour(ctx context.Context, linkID int64, hours int, filter domain.StatsFilter) ([]domain.HourStats, error) {
+	points, err := r.clickSeries(ctx, linkID, seriesHour, hours, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clicks by hour: %w", err)
+	}
+
+	stats := make([]domain.HourStats, len(points))
+	for i, p := range points {
+		stats[i] = domain.HourStats{Hour: p.label, Clicks: p.clicks, Visitors: p.visitors}
+	}
+	return stats, nil
+}
+
+// GetClicksByDay retrieves daily click counts for a link, or for every
+// link when linkID is 0, covering the filter's range or else the last days.
+func (r *ClickRepository) GetClicksByDay(ctx context.Context, linkID int64, days int, filter domain.StatsFilter) ([]domain.DayStats, error) {
+	points, err := r.clickSeries(ctx, linkID, seriesDay, days, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get clicks by day: %w", err)
 	}
+
+	stats := make([]domain.DayStats, len(points))
+	for i, p := range points {
+		stats[i] = domain.DayStats{Date: p.label, Clicks: p.clicks, Visitors: p.visitors}
+	}
+	return stats, nil
+}
+
+// GetClicksByMonth retrieves monthly click counts for a link, newest first,
+// covering the filter's range or else the last months.
+func (r *ClickRepository) GetClicksByMonth(ctx context.Context, linkID int64, months int, filter domain.StatsFilter) ([]domain.MonthStats, error) {
+	points, err := r.clickSeries(ctx, linkID, seriesMonth, months, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clicks by month: %w", err)
+	}
+
+	stats := make([]domain.MonthStats, len(points))
+	for i, p := range points {
+		stats[len(points)-1-i] = domain.MonthStats{Month: p.label, Clicks: p.clicks}
+	}
+	return stats, nil
+}
+
+// GetTopReferrers retrieves the most common referrers for a link.
+func (r *ClickRepository) GetTopReferrers(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.ReferrerStats, error) {
+	src, err := r.source(ctx, linkID, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	query, args := src.countQuery("referrer", "click_daily_referrers", "1 = 1")
+
+	rows, err := r.db.QueryContext(ctx, query+` LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top referrers: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.ReferrerStats
+	for rows.Next() {
+		var s domain.ReferrerStats
+		if err := rows.Scan(&s.Referrer, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan referrer stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetTopCountries retrieves the countries with the most clicks for a link.
+// Clicks without a resolved location are excluded.
+func (r *ClickRepository) GetTopCountries(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.CountryStats, error) {
+	src, err := r.source(ctx, linkID, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	query, args := src.countQuery("country", "click_daily_countries", "country != ''")
+
+	rows, err := r.db.QueryContext(ctx, query+` LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top countries: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.CountryStats
+	for rows.Next() {
+		var s domain.CountryStats
+		if err := rows.Scan(&s.Country, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan country stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetTopCities retrieves the cities with the most clicks for a link.
+func (r *ClickRepository) GetTopCities(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.CityStats, error) {
+	where, args := clickFilterClause(linkID, filter)
+
+	query := `
+		SELECT city, region, country, COUNT(*) as clicks
+		FROM clicks
+		WHERE ` + where + ` AND city != ''
+		GROUP BY country, region, city
+		ORDER BY clicks DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top cities: %w", err)
+	}
 	defer rows.Close()
 
-	var stats []domain.DayStats
+	var stats []domain.CityStats
 	for rows.Next() {
-		var s domain.DayStats
-		if err := rows.Scan(&s.Date, &s.Clicks); err != nil {
-			return nil, fmt.Errorf("failed to scan day stats: %w", err)
+		var s domain.CityStats
+		if err := rows.Scan(&s.City, &s.Region, &s.Country, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan city stats: %w", err)
 		}
 		stats = append(stats, s)
 	}
@@ -143,29 +505,31 @@ func (r *ClickRepository) GetClicksByDay(ctx context.Context, linkID int64, days
 	return stats, rows.Err()
 }
 
-// GetClicksByMonth retrieves monthly click counts for a link.
-func (r *ClickRepository) GetClicksByMonth(ctx context.Context, linkID int64, months int) ([]domain.MonthStats, error) {
-	startDate := time.Now().AddDate(0, -months, 0).Format("2006-01")
+// GetTopBrowsers retrieves the browser families with the most clicks for a
+// link. Clicks recorded before User-Agent parsing are excluded.
+func (r *ClickRepository) GetTopBrowsers(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.BrowserStats, error) {
+	where, args := clickFilterClause(linkID, filter)
 
 	query := `
-		SELECT strftime('%Y-%m', timestamp) as month, COUNT(*) as clicks
+		SELECT COALESCE(NULLIF(browser, ''), 'Other') as name, COUNT(*) as clicks
 		FROM clicks
-		WHERE link_id = ? AND strftime('%Y-%m', timestamp) >= ?
-		GROUP BY strftime('%Y-%m', timestamp)
-		ORDER BY month DESC
+		WHERE ` + where + ` AND device_type != ''
+		GROUP BY name
+		ORDER BY clicks DESC
+		LIMIT ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, linkID, startDate)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get clicks by month: %w", err)
+		return nil, fmt.Errorf("failed to get top browsers: %w", err)
 	}
 	defer rows.Close()
 
-	var stats []domain.MonthStats
+	var stats []domain.BrowserStats
 	for rows.Next() {
-		var s domain.MonthStats
-		if err := rows.Scan(&s.Month, &s.Clicks); err != nil {
-			return nil, fmt.Errorf("failed to scan month stats: %w", err)
+		var s domain.BrowserStats
+		if err := rows.Scan(&s.Browser, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan browser stats: %w", err)
 		}
 		stats = append(stats, s)
 	}
@@ -173,28 +537,30 @@ func (r *ClickRepository) GetClicksByMonth(ctx context.Context, linkID int64, mo
 	return stats, rows.Err()
 }
 
-// GetTopReferrers retrieves the most common referrers for a link.
-func (r *ClickRepository) GetTopReferrers(ctx context.Context, linkID int64, limit int) ([]domain.ReferrerStats, error) {
+// GetTopOS retrieves the operating system families with the most clicks for a link.
+func (r *ClickRepository) GetTopOS(ctx context.Context, linkID int64, limit int, filter domain.StatsFilter) ([]domain.OSStats, error) {
+	where, args := clickFilterClause(linkID, filter)
+
 	query := `
-		SELECT referrer, COUNT(*) as clicks
+		SELECT COALESCE(NULLIF(os, ''), 'Other') as name, COUNT(*) as clicks
 		FROM clicks
-		WHERE link_id = ?
-		GROUP BY referrer
+		WHERE ` + where + ` AND device_type != ''
+		GROUP BY name
 		ORDER BY clicks DESC
 		LIMIT ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, linkID, limit)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get top referrers: %w", err)
+		return nil, fmt.Errorf("failed to get top operating systems: %w", err)
 	}
 	defer rows.Close()
 
-	var stats []domain.ReferrerStats
+	var stats []domain.OSStats
 	for rows.Next() {
-		var s domain.ReferrerStats
-		if err := rows.Scan(&s.Referrer, &s.Clicks); err != nil {
-			return nil, fmt.Errorf("failed to scan referrer stats: %w", err)
+		var s domain.OSStats
+		if err := rows.Scan(&s.OS, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan OS stats: %w", err)
 		}
 		stats = append(stats, s)
 	}
@@ -202,12 +568,162 @@ func (r *ClickRepository) GetTopReferrers(ctx context.Context, linkID int64, lim
 	return stats, rows.Err()
 }
 
-// DeleteByLinkID removes all clicks for a link.
-func (r *ClickRepository) DeleteByLinkID(ctx context.Context, linkID int64) error {
-	query := `DELETE FROM clicks WHERE link_id = ?`
+// GetDeviceTypes retrieves click counts per device class for a link.
+func (r *ClickRepository) GetDeviceTypes(ctx context.Context, linkID int64, filter domain.StatsFilter) ([]domain.DeviceStats, error) {
+	src, err := r.source(ctx, linkID, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	query, args := src.countQuery("device_type", "click_daily_devices", "device_type != ''")
 
-	_, err := r.db.ExecContext(ctx, query, linkID)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
+		return nil, fmt.Errorf("failed to get device types: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.DeviceStats
+	for rows.Next() {
+		var s domain.DeviceStats
+		if err := rows.Scan(&s.DeviceType, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan device stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetChannels retrieves click counts per referrer channel for a link, each
+// with its top referrer hosts.
+func (r *ClickRepository) GetChannels(ctx context.Context, linkID int64, hostsPerChannel int, filter domain.StatsFilter) ([]domain.ChannelStats, error) {
+	where, args := clickFilterClause(linkID, filter)
+
+	query := `
+		SELECT channel, referrer_host, COUNT(*) as clicks
+		FROM clicks
+		WHERE ` + where + ` AND channel != ''
+		GROUP BY channel, referrer_host
+		ORDER BY clicks DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channels: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.ChannelStats
+	index := make(map[string]int)
+	for rows.Next() {
+		var channel, host string
+		var clicks int64
+		if err := rows.Scan(&channel, &host, &clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan channel stats: %w", err)
+		}
+
+		i, ok := index[channel]
+		if !ok {
+			i = len(stats)
+			index[channel] = i
+			stats = append(stats, domain.ChannelStats{Channel: channel})
+		}
+		stats[i].Clicks += clicks
+		if host != "" && len(stats[i].Hosts) < hostsPerChannel {
+			stats[i].Hosts = append(stats[i].Hosts, domain.ReferrerStats{Referrer: host, Clicks: clicks})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Clicks > stats[j].Clicks
+	})
+
+	return stats, nil
+}
+
+// GetSources retrieves click counts per click source for a link. Clicks
+// recorded before sources were tracked are excluded.
+func (r *ClickRepository) GetSources(ctx context.Context, linkID int64, filter domain.StatsFilter) ([]domain.SourceStats, error) {
+	where, args := clickFilterClause(linkID, filter)
+
+	query := `
+		SELECT source, COUNT(*) as clicks
+		FROM clicks
+		WHERE ` + where + ` AND source != ''
+		GROUP BY source
+		ORDER BY clicks DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sources: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.SourceStats
+	for rows.Next() {
+		var s domain.SourceStats
+		if err := rows.Scan(&s.Source, &s.Clicks); err != nil {
+			return nil, fmt.Errorf("failed to scan source stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// botStats fills in the bot clicks of stats for the filter's range,
+// regardless of whether the filter counts bots elsewhere. Bot clicks are
+// not rolled up, so pruned ones are no longer counted.
+func (r *ClickRepository) botStats(ctx context.Context, linkID int64, filter domain.StatsFilter, stats *domain.ClickStats) error {
+	filter.Bots = domain.BotsOnly
+	where, args := clickFilterClause(linkID, filter)
+
+	query := `
+		SELECT bot, COUNT(*) as clicks
+		FROM clicks
+		WHERE ` + where + `
+		GROUP BY bot
+		ORDER BY clicks DESC, bot
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get bot clicks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s domain.BotStats
+		if err := rows.Scan(&s.Class, &s.Clicks); err != nil {
+			return fmt.Errorf("failed to scan bot stats: %w", err)
+		}
+		stats.Bots = append(stats.Bots, s)
+		stats.BotClicks += s.Clicks
+	}
+
+	return rows.Err()
+}
+
+// DeleteByLinkID removes all clicks for a link, including their rollups
+// and conversions.
+func (r *ClickRepository) DeleteByLinkID(ctx context.Context, linkID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete clicks: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"conversions", "clicks", "click_daily", "click_daily_referrers", "click_daily_devices", "click_daily_countries"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE link_id = ?`, linkID); err != nil {
+			return fmt.Errorf("failed to delete clicks: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to delete clicks: %w", err)
 	}
 
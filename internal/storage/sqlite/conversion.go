@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// RecordConversion stores a conversion for the click with the given token.
+// A click converts once per goal; reporting it again returns the existing
+// conversion.
+func (r *ClickRepository) RecordConversion(ctx context.Context, conv *domain.Conversion, token string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record conversion: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `SELECT id, link_id, COALESCE(channel, '') FROM clicks WHERE token = ?`, token).
+		Scan(&conv.ClickID, &conv.LinkID, &conv.Channel)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrClickNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find click: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO conversions (link_id, click_id, goal, channel, timestamp) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(click_id, goal) DO NOTHING`,
+		conv.LinkID, conv.ClickID, conv.Goal, conv.Channel, conv.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to record conversion: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT id, timestamp FROM conversions WHERE click_id = ? AND goal = ?`, conv.ClickID, conv.Goal).
+		Scan(&conv.ID, &conv.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to record conversion: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record conversion: %w", err)
+	}
+	return nil
+}
+
+// conversionStats fills in the conversion counts of stats, which must
+// already hold the total clicks for the same filter. Conversions carry the
+// link, channel and time columns of clicks, so they are filtered alike.
+func (r *ClickRepository) conversionStats(ctx context.Context, linkID int64, filter domain.StatsFilter, stats *domain.ClickStats) error {
+	// Bots get no conversion tokens, so bot traffic has no conversions.
+	if filter.Bots == domain.BotsOnly {
+		return nil
+	}
+
+	where, args := eventFilterClause(linkID, filter)
+	query := `
+		SELECT goal, COUNT(*) AS conversions
+		FROM conversions
+		WHERE ` + where + `
+		GROUP BY goal
+		ORDER BY conversions DESC, goal`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get conversions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g domain.GoalStats
+		if err := rows.Scan(&g.Goal, &g.Conversions); err != nil {
+			return err
+		}
+		g.Rate = domain.ConversionRate(g.Conversions, stats.TotalClicks)
+		stats.Goals = append(stats.Goals, g)
+		stats.Conversions += g.Conversions
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	stats.ConversionRate = domain.ConversionRate(stats.Conversions, stats.TotalClicks)
+	return nil
+}
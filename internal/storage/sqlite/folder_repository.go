@@ -18,9 +18,14 @@ func NewFolderRepository(db *DB) *FolderRepository {
 }
 
 func (r *FolderRepository) Create(ctx context.Context, folder *domain.Folder) (*domain.Folder, error) {
-	query := `INSERT INTO folders (name, parent_id, created_at) VALUES (?, ?, ?)`
+	privacyJSON, err := folder.Privacy.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal privacy: %w", err)
+	}
+
+	query := `INSERT INTO folders (name, parent_id, privacy, created_at) VALUES (?, ?, ?, ?)`
 
-	result, err := r.db.ExecContext(ctx, query, folder.Name, folder.ParentID, folder.CreatedAt)
+	result, err := r.db.ExecContext(ctx, query, folder.Name, folder.ParentID, privacyJSON, folder.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create folder: %w", err)
 	}
@@ -35,12 +40,9 @@ func (r *FolderRepository) Create(ctx context.Context, folder *domain.Folder) (*
 }
 
 func (r *FolderRepository) GetByID(ctx context.Context, id int64) (*domain.Folder, error) {
-	query := `SELECT id, name, parent_id, created_at FROM folders WHERE id = ?`
-
-	folder := &domain.Folder{}
-	var parentID sql.NullInt64
+	query := `SELECT id, name, parent_id, created_at, privacy FROM folders WHERE id = ?`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&folder.ID, &folder.Name, &parentID, &folder.CreatedAt)
+	folder, err := scanFolder(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrFolderNotFound
@@ -48,15 +50,11 @@ func (r *FolderRepository) GetByID(ctx context.Context, id int64) (*domain.Folde
 		return nil, fmt.Errorf("failed to get folder: %w", err)
 	}
 
-	if parentID.Valid {
-		folder.ParentID = &parentID.Int64
-	}
-
 	return folder, nil
 }
 
 func (r *FolderRepository) List(ctx context.Context) ([]*domain.Folder, error) {
-	query := `SELECT id, name, parent_id, created_at FROM folders ORDER BY name ASC`
+	query := `SELECT id, name, parent_id, created_at, privacy FROM folders ORDER BY name ASC`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -66,17 +64,10 @@ func (r *FolderRepository) List(ctx context.Context) ([]*domain.Folder, error) {
 
 	var folders []*domain.Folder
 	for rows.Next() {
-		folder := &domain.Folder{}
-		var parentID sql.NullInt64
-
-		if err := rows.Scan(&folder.ID, &folder.Name, &parentID, &folder.CreatedAt); err != nil {
+		folder, err := scanFolder(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan folder: %w", err)
 		}
-
-		if parentID.Valid {
-			folder.ParentID = &parentID.Int64
-		}
-
 		folders = append(folders, folder)
 	}
 
@@ -87,6 +78,32 @@ func (r *FolderRepository) List(ctx context.Context) ([]*domain.Folder, error) {
 	return folders, nil
 }
 
+// Update stores a folder's name and privacy defaults.
+func (r *FolderRepository) Update(ctx context.Context, folder *domain.Folder) error {
+	privacyJSON, err := folder.Privacy.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal privacy: %w", err)
+	}
+
+	query := `UPDATE folders SET name = ?, privacy = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, folder.Name, privacyJSON, folder.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update folder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrFolderNotFound
+	}
+
+	return nil
+}
+
 func (r *FolderRepository) Delete(ctx context.Context, id int64) error {
 	query := `DELETE FROM folders WHERE id = ?`
 
@@ -106,3 +123,24 @@ func (r *FolderRepository) Delete(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+// scanFolder reads a folder selected as id, name, parent_id, created_at,
+// privacy.
+func scanFolder(row rowScanner) (*domain.Folder, error) {
+	folder := &domain.Folder{}
+	var parentID sql.NullInt64
+	var privacyJSON sql.NullString
+
+	if err := row.Scan(&folder.ID, &folder.Name, &parentID, &folder.CreatedAt, &privacyJSON); err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		folder.ParentID = &parentID.Int64
+	}
+	if err := folder.Privacy.ParseJSON(privacyJSON.String); err != nil {
+		return nil, fmt.Errorf("failed to parse privacy: %w", err)
+	}
+
+	return folder, nil
+}
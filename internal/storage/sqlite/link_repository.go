@@ -11,6 +11,84 @@ import (
 	"github.com/aftaab/trelay/internal/core/domain"
 )
 
+// linkColumns lists the links columns read by scanLink, in scan order.
+const linkColumns = `id, slug, original_url, domain, password_hash, expires_at, tags, folder_id, is_one_time,
+	og_title, og_description, og_image_url, safety_override, preview, health_checked_at, health_status_code, health_redirect_url, health_failure_streak,
+	health_error, click_count, created_at, updated_at, deleted_at, privacy`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLink reads a single link selected with linkColumns.
+func scanLink(row rowScanner) (*domain.Link, error) {
+	link := &domain.Link{}
+	var tagsJSON, privacyJSON string
+	// preview is nullable in the schema; a NULL reads as no preview.
+	var previewJSON sql.NullString
+	var expiresAt, deletedAt, healthCheckedAt sql.NullTime
+	var folderID sql.NullInt64
+	var health domain.LinkHealth
+
+	err := row.Scan(
+		&link.ID,
+		&link.Slug,
+		&link.OriginalURL,
+		&link.Domain,
+		&link.PasswordHash,
+		&expiresAt,
+		&tagsJSON,
+		&folderID,
+		&link.IsOneTime,
+		&link.OGTitle,
+		&link.OGDescription,
+		&link.OGImageURL,
+		&link.SafetyOverride,
+		&previewJSON,
+		&healthCheckedAt,
+		&health.StatusCode,
+		&health.RedirectURL,
+		&health.FailureStreak,
+		&health.Error,
+		&link.ClickCount,
+		&link.CreatedAt,
+		&link.UpdatedAt,
+		&deletedAt,
+		&privacyJSON,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	if deletedAt.Valid {
+		link.DeletedAt = &deletedAt.Time
+	}
+	if folderID.Valid {
+		link.FolderID = &folderID.Int64
+	}
+	if healthCheckedAt.Valid {
+		health.CheckedAt = &healthCheckedAt.Time
+		link.Health = &health
+	}
+
+	if err := link.ParseTagsJSON(tagsJSON); err != nil {
+		return nil, fmt.Errorf("failed to parse tags: %w", err)
+	}
+	if err := link.ParsePreviewJSON(previewJSON.String); err != nil {
+		return nil, fmt.Errorf("failed to parse preview: %w", err)
+	}
+	if err := link.Privacy.ParseJSON(privacyJSON); err != nil {
+		return nil, fmt.Errorf("failed to parse privacy: %w", err)
+	}
+
+	link.HasPassword = link.PasswordHash != ""
+	return link, nil
+}
+
 // LinkRepository implements port.LinkRepository for SQLite.
 type LinkRepository struct {
 	db *DB
@@ -27,13 +105,17 @@ func (r *LinkRepository) Create(ctx context.Context, link *domain.Link) (*domain
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal tags: %w", err)
 	}
+	privacyJSON, err := link.Privacy.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal privacy: %w", err)
+	}
 
 	// Remove any soft-deleted link with the same slug to allow reuse
 	_, _ = r.db.ExecContext(ctx, `DELETE FROM links WHERE slug = ? AND deleted_at IS NOT NULL`, link.Slug)
 
 	query := `
-		INSERT INTO links (slug, original_url, domain, password_hash, expires_at, tags, folder_id, is_one_time, og_title, og_description, og_image_url, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO links (slug, original_url, domain, password_hash, expires_at, tags, folder_id, is_one_time, og_title, og_description, og_image_url, safety_override, privacy, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
@@ -48,6 +130,8 @@ func (r *LinkRepository) Create(ctx context.Context, link *domain.Link) (*domain
 		link.OGTitle,
 		link.OGDescription,
 		link.OGImageURL,
+		link.SafetyOverride,
+		privacyJSON,
 		link.CreatedAt,
 		link.UpdatedAt,
 	)
@@ -70,35 +154,9 @@ func (r *LinkRepository) Create(ctx context.Context, link *domain.Link) (*domain
 
 // GetBySlug retrieves a link by its slug.
 func (r *LinkRepository) GetBySlug(ctx context.Context, slug string) (*domain.Link, error) {
-	query := `
-		SELECT id, slug, original_url, domain, password_hash, expires_at, tags, folder_id, is_one_time, og_title, og_description, og_image_url, click_count, created_at, updated_at, deleted_at
-		FROM links
-		WHERE slug = ?
-	`
+	query := `SELECT ` + linkColumns + ` FROM links WHERE slug = ?`
 
-	link := &domain.Link{}
-	var tagsJSON string
-	var expiresAt, deletedAt sql.NullTime
-	var folderID sql.NullInt64
-
-	err := r.db.QueryRowContext(ctx, query, slug).Scan(
-		&link.ID,
-		&link.Slug,
-		&link.OriginalURL,
-		&link.Domain,
-		&link.PasswordHash,
-		&expiresAt,
-		&tagsJSON,
-		&folderID,
-		&link.IsOneTime,
-		&link.OGTitle,
-		&link.OGDescription,
-		&link.OGImageURL,
-		&link.ClickCount,
-		&link.CreatedAt,
-		&link.UpdatedAt,
-		&deletedAt,
-	)
+	link, err := scanLink(r.db.QueryRowContext(ctx, query, slug))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrLinkNotFound
@@ -106,55 +164,14 @@ func (r *LinkRepository) GetBySlug(ctx context.Context, slug string) (*domain.Li
 		return nil, fmt.Errorf("failed to get link: %w", err)
 	}
 
-	if expiresAt.Valid {
-		link.ExpiresAt = &expiresAt.Time
-	}
-	if deletedAt.Valid {
-		link.DeletedAt = &deletedAt.Time
-	}
-	if folderID.Valid {
-		link.FolderID = &folderID.Int64
-	}
-
-	if err := link.ParseTagsJSON(tagsJSON); err != nil {
-		return nil, fmt.Errorf("failed to parse tags: %w", err)
-	}
-
-	link.HasPassword = link.PasswordHash != ""
 	return link, nil
 }
 
 // GetByID retrieves a link by its ID.
 func (r *LinkRepository) GetByID(ctx context.Context, id int64) (*domain.Link, error) {
-	query := `
-		SELECT id, slug, original_url, domain, password_hash, expires_at, tags, folder_id, is_one_time, og_title, og_description, og_image_url, click_count, created_at, updated_at, deleted_at
-		FROM links
-		WHERE id = ?
-	`
+	query := `SELECT ` + linkColumns + ` FROM links WHERE id = ?`
 
-	link := &domain.Link{}
-	var tagsJSON string
-	var expiresAt, deletedAt sql.NullTime
-	var folderID sql.NullInt64
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&link.ID,
-		&link.Slug,
-		&link.OriginalURL,
-		&link.Domain,
-		&link.PasswordHash,
-		&expiresAt,
-		&tagsJSON,
-		&folderID,
-		&link.IsOneTime,
-		&link.OGTitle,
-		&link.OGDescription,
-		&link.OGImageURL,
-		&link.ClickCount,
-		&link.CreatedAt,
-		&link.UpdatedAt,
-		&deletedAt,
-	)
+	link, err := scanLink(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrLinkNotFound
@@ -162,38 +179,38 @@ func (r *LinkRepository) GetByID(ctx context.Context, id int64) (*domain.Link, e
 		return nil, fmt.Errorf("failed to get link: %w", err)
 	}
 
-	if expiresAt.Valid {
-		link.ExpiresAt = &expiresAt.Time
-	}
-	if deletedAt.Valid {
-		link.DeletedAt = &deletedAt.Time
-	}
-	if folderID.Valid {
-		link.FolderID = &folderID.Int64
-	}
-
-	if err := link.ParseTagsJSON(tagsJSON); err != nil {
-		return nil, fmt.Errorf("failed to parse tags: %w", err)
-	}
-
-	link.HasPassword = link.PasswordHash != ""
 	return link, nil
 }
 
-// Update modifies an existing link.
+// Update modifies an existing link. A stored preview describes the old
+// destination, so it is dropped when original_url changes.
 func (r *LinkRepository) Update(ctx context.Context, link *domain.Link) error {
 	tagsJSON, err := link.TagsJSON()
 	if err != nil {
 		return fmt.Errorf("failed to marshal tags: %w", err)
 	}
+	privacyJSON, err := link.Privacy.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal privacy: %w", err)
+	}
 
 	query := `
 		UPDATE links
-		SET original_url = ?, domain = ?, password_hash = ?, expires_at = ?, tags = ?, folder_id = ?, og_title = ?, og_description = ?, og_image_url = ?, updated_at = ?
+		SET preview = CASE WHEN original_url = ? THEN preview ELSE '' END,
+			preview_fetched_at = CASE WHEN original_url = ? THEN preview_fetched_at END,
+			original_url = ?, domain = ?, password_hash = ?, expires_at = ?, tags = ?, folder_id = ?, og_title = ?, og_description = ?, og_image_url = ?, safety_override = ?, privacy = ?,
+			health_checked_at = ?, health_status_code = ?, health_redirect_url = ?, health_failure_streak = ?, health_error = ?, updated_at = ?
 		WHERE id = ?
 	`
 
+	health := link.Health
+	if health == nil {
+		health = &domain.LinkHealth{}
+	}
+
 	result, err := r.db.ExecContext(ctx, query,
+		link.OriginalURL,
+		link.OriginalURL,
 		link.OriginalURL,
 		link.Domain,
 		link.PasswordHash,
@@ -203,6 +220,13 @@ func (r *LinkRepository) Update(ctx context.Context, link *domain.Link) error {
 		link.OGTitle,
 		link.OGDescription,
 		link.OGImageURL,
+		link.SafetyOverride,
+		privacyJSON,
+		health.CheckedAt,
+		health.StatusCode,
+		health.RedirectURL,
+		health.FailureStreak,
+		health.Error,
 		time.Now(),
 		link.ID,
 	)
@@ -285,8 +309,8 @@ func (r *LinkRepository) Restore(ctx context.Context, slug string) error {
 	return nil
 }
 
-// List retrieves links matching the filter criteria.
-func (r *LinkRepository) List(ctx context.Context, filter domain.ListLinksFilter) ([]*domain.Link, error) {
+// linkFilterClause builds the WHERE clause shared by List and Count.
+func linkFilterClause(filter domain.ListLinksFilter) (string, []interface{}) {
 	var conditions []string
 	var args []interface{}
 
@@ -347,15 +371,24 @@ func (r *LinkRepository) List(ctx context.Context, filter domain.ListLinksFilter
 		args = append(args, filter.ExpiresBefore)
 	}
 
-	query := `
-		SELECT id, slug, original_url, domain, password_hash, expires_at, tags, folder_id, is_one_time, og_title, og_description, og_image_url, click_count, created_at, updated_at, deleted_at
-		FROM links
-	`
+	if filter.Broken != nil {
+		if *filter.Broken {
+			conditions = append(conditions, "health_failure_streak > 0")
+		} else {
+			conditions = append(conditions, "health_failure_streak = 0")
+		}
+	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if len(conditions) == 0 {
+		return "", args
 	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
 
+// List retrieves links matching the filter criteria.
+func (r *LinkRepository) List(ctx context.Context, filter domain.ListLinksFilter) ([]*domain.Link, error) {
+	where, args := linkFilterClause(filter)
+	query := `SELECT ` + linkColumns + ` FROM links` + where
 	query += " ORDER BY created_at DESC"
 
 	if filter.Limit > 0 {
@@ -374,48 +407,10 @@ func (r *LinkRepository) List(ctx context.Context, filter domain.ListLinksFilter
 
 	var links []*domain.Link
 	for rows.Next() {
-		link := &domain.Link{}
-		var tagsJSON string
-		var expiresAt, deletedAt sql.NullTime
-		var folderID sql.NullInt64
-
-		err := rows.Scan(
-			&link.ID,
-			&link.Slug,
-			&link.OriginalURL,
-			&link.Domain,
-			&link.PasswordHash,
-			&expiresAt,
-			&tagsJSON,
-			&folderID,
-			&link.IsOneTime,
-			&link.OGTitle,
-			&link.OGDescription,
-			&link.OGImageURL,
-			&link.ClickCount,
-			&link.CreatedAt,
-			&link.UpdatedAt,
-			&deletedAt,
-		)
+		link, err := scanLink(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan link: %w", err)
 		}
-
-		if expiresAt.Valid {
-			link.ExpiresAt = &expiresAt.Time
-		}
-		if deletedAt.Valid {
-			link.DeletedAt = &deletedAt.Time
-		}
-		if folderID.Valid {
-			link.FolderID = &folderID.Int64
-		}
-
-		if err := link.ParseTagsJSON(tagsJSON); err != nil {
-			return nil, fmt.Errorf("failed to parse tags: %w", err)
-		}
-
-		link.HasPassword = link.PasswordHash != ""
 		links = append(links, link)
 	}
 
@@ -428,114 +423,224 @@ func (r *LinkRepository) List(ctx context.Context, filter domain.ListLinksFilter
 
 // Count returns the total number of links matching the filter.
 func (r *LinkRepository) Count(ctx context.Context, filter domain.ListLinksFilter) (int64, error) {
-	var conditions []string
-	var args []interface{}
+	where, args := linkFilterClause(filter)
+	query := `SELECT COUNT(*) FROM links` + where
 
-	if filter.OnlyDeleted {
-		conditions = append(conditions, "deleted_at IS NOT NULL")
-	} else if !filter.IncludeDeleted {
-		conditions = append(conditions, "deleted_at IS NULL")
+	var count int64
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count links: %w", err)
 	}
 
-	if filter.Search != "" {
-		conditions = append(conditions, "(slug LIKE ? OR original_url LIKE ?)")
-		searchTerm := "%" + filter.Search + "%"
-		args = append(args, searchTerm, searchTerm)
-	}
+	return count, nil
+}
 
-	if filter.Domain != "" {
-		conditions = append(conditions, "domain = ?")
-		args = append(args, filter.Domain)
+// SlugExists checks if a slug is already in use.
+func (r *LinkRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	// Only check non-deleted links so slugs can be reused after deletion
+	query := `SELECT EXISTS(SELECT 1 FROM links WHERE slug = ? AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check slug existence: %w", err)
 	}
 
-	if filter.FolderID != nil {
-		conditions = append(conditions, "folder_id = ?")
-		args = append(args, *filter.FolderID)
+	return exists, nil
+}
+
+// IncrementClickCount atomically increments the click count for a link.
+func (r *LinkRepository) IncrementClickCount(ctx context.Context, linkID int64) error {
+	query := `UPDATE links SET click_count = click_count + 1 WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, linkID)
+	if err != nil {
+		return fmt.Errorf("failed to increment click count: %w", err)
 	}
 
-	if len(filter.Tags) > 0 {
-		for _, tag := range filter.Tags {
-			conditions = append(conditions, "tags LIKE ?")
-			args = append(args, "%\""+tag+"\"%")
-		}
+	return nil
+}
+
+// AddClickCounts applies coalesced click increments in one transaction.
+func (r *LinkRepository) AddClickCounts(ctx context.Context, counts map[int64]int64) error {
+	if len(counts) == 0 {
+		return nil
 	}
 
-	if filter.CreatedAfter != "" {
-		conditions = append(conditions, "created_at >= ?")
-		args = append(args, filter.CreatedAfter)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin click count update: %w", err)
 	}
+	defer tx.Rollback()
 
-	if filter.CreatedBefore != "" {
-		conditions = append(conditions, "created_at <= ?")
-		args = append(args, filter.CreatedBefore)
+	stmt, err := tx.PrepareContext(ctx, `UPDATE links SET click_count = click_count + ? WHERE id = ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare click count update: %w", err)
 	}
+	defer stmt.Close()
 
-	if filter.HasExpiry != nil {
-		if *filter.HasExpiry {
-			conditions = append(conditions, "expires_at IS NOT NULL")
-		} else {
-			conditions = append(conditions, "expires_at IS NULL")
+	for linkID, n := range counts {
+		if _, err := stmt.ExecContext(ctx, n, linkID); err != nil {
+			return fmt.Errorf("failed to add click count: %w", err)
 		}
 	}
 
-	if filter.ExpiresAfter != "" {
-		conditions = append(conditions, "expires_at IS NOT NULL AND expires_at >= ?")
-		args = append(args, filter.ExpiresAfter)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit click counts: %w", err)
 	}
 
-	if filter.ExpiresBefore != "" {
-		conditions = append(conditions, "expires_at IS NOT NULL AND expires_at <= ?")
-		args = append(args, filter.ExpiresBefore)
+	return nil
+}
+
+func (r *LinkRepository) Burn(ctx context.Context, linkID int64) error {
+	query := `UPDATE links SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now(), linkID)
+	if err != nil {
+		return fmt.Errorf("failed to burn one-time link: %w", err)
 	}
 
-	query := `SELECT COUNT(*) FROM links`
+	return nil
+}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+// UpdatePreview stores a fetched preview for a link, but only if the link
+// still points at forURL. A nil preview only bumps preview_fetched_at so a
+// failing destination is not retried on every refresh pass.
+func (r *LinkRepository) UpdatePreview(ctx context.Context, linkID int64, forURL string, preview *domain.LinkPreview) error {
+	now := time.Now()
+
+	if preview == nil {
+		query := `UPDATE links SET preview_fetched_at = ? WHERE id = ? AND original_url = ?`
+		if _, err := r.db.ExecContext(ctx, query, now, linkID, forURL); err != nil {
+			return fmt.Errorf("failed to touch link preview: %w", err)
+		}
+		return nil
 	}
 
-	var count int64
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	data, err := (&domain.Link{Preview: preview}).PreviewJSON()
 	if err != nil {
-		return 0, fmt.Errorf("failed to count links: %w", err)
+		return fmt.Errorf("failed to marshal preview: %w", err)
 	}
 
-	return count, nil
+	query := `UPDATE links SET preview = ?, preview_fetched_at = ? WHERE id = ? AND original_url = ?`
+	if _, err := r.db.ExecContext(ctx, query, data, now, linkID, forURL); err != nil {
+		return fmt.Errorf("failed to update link preview: %w", err)
+	}
+
+	return nil
 }
 
-// SlugExists checks if a slug is already in use.
-func (r *LinkRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
-	// Only check non-deleted links so slugs can be reused after deletion
-	query := `SELECT EXISTS(SELECT 1 FROM links WHERE slug = ? AND deleted_at IS NULL)`
+// ListStalePreviews returns live links whose preview was never fetched or
+// was fetched before the given time, oldest first.
+func (r *LinkRepository) ListStalePreviews(ctx context.Context, fetchedBefore time.Time, limit int) ([]*domain.Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM links
+		WHERE deleted_at IS NULL AND (preview_fetched_at IS NULL OR preview_fetched_at < ?)
+		ORDER BY preview_fetched_at IS NOT NULL, preview_fetched_at ASC
+		LIMIT ?`
 
-	var exists bool
-	err := r.db.QueryRowContext(ctx, query, slug).Scan(&exists)
+	rows, err := r.db.QueryContext(ctx, query, fetchedBefore, limit)
 	if err != nil {
-		return false, fmt.Errorf("failed to check slug existence: %w", err)
+		return nil, fmt.Errorf("failed to list stale previews: %w", err)
 	}
+	defer rows.Close()
 
-	return exists, nil
+	var links []*domain.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
 }
 
-// IncrementClickCount atomically increments the click count for a link.
-func (r *LinkRepository) IncrementClickCount(ctx context.Context, linkID int64) error {
-	query := `UPDATE links SET click_count = click_count + 1 WHERE id = ?`
+// UpdateHealth records the outcome of a destination check, but only if the
+// link still points at forURL. A failed check extends the failure streak; a
+// successful one resets it.
+func (r *LinkRepository) UpdateHealth(ctx context.Context, linkID int64, forURL string, health *domain.LinkHealth, failed bool) error {
+	checkedAt := time.Now()
+	if health.CheckedAt != nil {
+		checkedAt = *health.CheckedAt
+	}
 
-	_, err := r.db.ExecContext(ctx, query, linkID)
+	query := `
+		UPDATE links
+		SET health_checked_at = ?, health_status_code = ?, health_redirect_url = ?, health_error = ?,
+			health_failure_streak = CASE WHEN ? THEN health_failure_streak + 1 ELSE 0 END
+		WHERE id = ? AND original_url = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		checkedAt,
+		health.StatusCode,
+		health.RedirectURL,
+		health.Error,
+		failed,
+		linkID,
+		forURL,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to increment click count: %w", err)
+		return fmt.Errorf("failed to update link health: %w", err)
 	}
 
 	return nil
 }
 
-func (r *LinkRepository) Burn(ctx context.Context, linkID int64) error {
-	query := `UPDATE links SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+// ListDueForCheck returns live, unexpired links that were never checked or
+// were last checked before the given time, oldest first.
+func (r *LinkRepository) ListDueForCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]*domain.Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM links
+		WHERE deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+			AND (health_checked_at IS NULL OR health_checked_at < ?)
+		ORDER BY health_checked_at IS NOT NULL, health_checked_at ASC
+		LIMIT ?`
 
-	_, err := r.db.ExecContext(ctx, query, time.Now(), linkID)
+	rows, err := r.db.QueryContext(ctx, query, time.Now(), checkedBefore, limit)
 	if err != nil {
-		return fmt.Errorf("failed to burn one-time link: %w", err)
+		return nil, fmt.Errorf("failed to list links due for check: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var links []*domain.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// ListExpiredBetween returns live links whose expiry is before before and
+// after the cursor (after, afterID), ordered by expiry then ID. Links that
+// share an expiry time are told apart by ID, so a page can end partway
+// through them.
+func (r *LinkRepository) ListExpiredBetween(ctx context.Context, after time.Time, afterID int64, before time.Time, limit int) ([]*domain.Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM links
+		WHERE deleted_at IS NULL
+			AND (expires_at > ? OR (expires_at = ? AND id > ?))
+			AND expires_at < ?
+		ORDER BY expires_at ASC, id ASC
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, after, after, afterID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*domain.Link
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
 }
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE links ADD COLUMN safety_override BOOLEAN DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE links DROP COLUMN safety_override;
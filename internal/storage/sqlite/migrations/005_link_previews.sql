@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE links ADD COLUMN preview TEXT DEFAULT '';
+ALTER TABLE links ADD COLUMN preview_fetched_at DATETIME;
+
+CREATE INDEX idx_links_preview_fetched_at ON links(preview_fetched_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_links_preview_fetched_at;
+ALTER TABLE links DROP COLUMN preview_fetched_at;
+ALTER TABLE links DROP COLUMN preview;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE links ADD COLUMN health_checked_at DATETIME;
+ALTER TABLE links ADD COLUMN health_status_code INTEGER DEFAULT 0;
+ALTER TABLE links ADD COLUMN health_redirect_url TEXT DEFAULT '';
+ALTER TABLE links ADD COLUMN health_failure_streak INTEGER DEFAULT 0;
+ALTER TABLE links ADD COLUMN health_error TEXT DEFAULT '';
+
+CREATE INDEX idx_links_health_checked_at ON links(health_checked_at);
+CREATE INDEX idx_links_health_failure_streak ON links(health_failure_streak);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_links_health_failure_streak;
+DROP INDEX IF EXISTS idx_links_health_checked_at;
+ALTER TABLE links DROP COLUMN health_error;
+ALTER TABLE links DROP COLUMN health_failure_streak;
+ALTER TABLE links DROP COLUMN health_redirect_url;
+ALTER TABLE links DROP COLUMN health_status_code;
+ALTER TABLE links DROP COLUMN health_checked_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE clicks ADD COLUMN country TEXT DEFAULT '';
+ALTER TABLE clicks ADD COLUMN region TEXT DEFAULT '';
+ALTER TABLE clicks ADD COLUMN city TEXT DEFAULT '';
+
+CREATE INDEX idx_clicks_link_country ON clicks(link_id, country);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_clicks_link_country;
+ALTER TABLE clicks DROP COLUMN city;
+ALTER TABLE clicks DROP COLUMN region;
+ALTER TABLE clicks DROP COLUMN country;
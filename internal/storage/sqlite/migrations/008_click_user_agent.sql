@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE clicks ADD COLUMN browser TEXT DEFAULT '';
+ALTER TABLE clicks ADD COLUMN browser_version TEXT DEFAULT '';
+ALTER TABLE clicks ADD COLUMN os TEXT DEFAULT '';
+ALTER TABLE clicks ADD COLUMN os_version TEXT DEFAULT '';
+ALTER TABLE clicks ADD COLUMN device_type TEXT DEFAULT '';
+
+CREATE INDEX idx_clicks_link_browser ON clicks(link_id, browser);
+CREATE INDEX idx_clicks_link_os ON clicks(link_id, os);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_clicks_link_os;
+DROP INDEX IF EXISTS idx_clicks_link_browser;
+ALTER TABLE clicks DROP COLUMN device_type;
+ALTER TABLE clicks DROP COLUMN os_version;
+ALTER TABLE clicks DROP COLUMN os;
+ALTER TABLE clicks DROP COLUMN browser_version;
+ALTER TABLE clicks DROP COLUMN browser;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE clicks ADD COLUMN referrer_host TEXT DEFAULT '';
+ALTER TABLE clicks ADD COLUMN channel TEXT DEFAULT '';
+
+-- Direct visits are unambiguous; other existing referrers stay unclassified.
+UPDATE clicks SET channel = 'direct' WHERE referrer = 'direct' OR referrer = '';
+
+CREATE INDEX idx_clicks_link_channel ON clicks(link_id, channel);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_clicks_link_channel;
+ALTER TABLE clicks DROP COLUMN channel;
+ALTER TABLE clicks DROP COLUMN referrer_host;
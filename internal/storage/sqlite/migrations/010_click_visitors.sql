@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE clicks ADD COLUMN visitor_id TEXT DEFAULT '';
+
+-- Unsalted IP hashes can be reversed by enumerating a /24; drop them.
+UPDATE clicks SET ip_hash = '';
+
+CREATE INDEX idx_clicks_link_visitor ON clicks(link_id, visitor_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_clicks_link_visitor;
+ALTER TABLE clicks DROP COLUMN visitor_id;
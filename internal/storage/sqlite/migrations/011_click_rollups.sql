@@ -0,0 +1,75 @@
+-- +goose Up
+-- Daily click totals per link and breakdown, kept when raw clicks are pruned.
+-- Days are UTC dates; visitors counts distinct visitor IDs on that day.
+CREATE TABLE click_daily (
+    link_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    visitors INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, day),
+    FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+CREATE TABLE click_daily_referrers (
+    link_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    referrer TEXT NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, day, referrer),
+    FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+CREATE TABLE click_daily_devices (
+    link_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    device_type TEXT NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, day, device_type),
+    FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+CREATE TABLE click_daily_countries (
+    link_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    country TEXT NOT NULL,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, day, country),
+    FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE
+);
+
+-- Workspace stats scan every link for a range of days.
+CREATE INDEX idx_click_daily_day ON click_daily(day);
+CREATE INDEX idx_click_daily_referrers_day ON click_daily_referrers(day);
+CREATE INDEX idx_click_daily_devices_day ON click_daily_devices(day);
+CREATE INDEX idx_click_daily_countries_day ON click_daily_countries(day);
+
+INSERT INTO click_daily (link_id, day, clicks, visitors)
+SELECT link_id, DATE(timestamp), COUNT(*), COUNT(DISTINCT NULLIF(visitor_id, ''))
+FROM clicks
+WHERE link_id IN (SELECT id FROM links)
+GROUP BY link_id, DATE(timestamp);
+
+INSERT INTO click_daily_referrers (link_id, day, referrer, clicks)
+SELECT link_id, DATE(timestamp), COALESCE(referrer, ''), COUNT(*)
+FROM clicks
+WHERE link_id IN (SELECT id FROM links)
+GROUP BY link_id, DATE(timestamp), COALESCE(referrer, '');
+
+INSERT INTO click_daily_devices (link_id, day, device_type, clicks)
+SELECT link_id, DATE(timestamp), COALESCE(device_type, ''), COUNT(*)
+FROM clicks
+WHERE link_id IN (SELECT id FROM links)
+GROUP BY link_id, DATE(timestamp), COALESCE(device_type, '');
+
+INSERT INTO click_daily_countries (link_id, day, country, clicks)
+SELECT link_id, DATE(timestamp), COALESCE(country, ''), COUNT(*)
+FROM clicks
+WHERE link_id IN (SELECT id FROM links)
+GROUP BY link_id, DATE(timestamp), COALESCE(country, '');
+
+-- +goose Down
+DROP TABLE IF EXISTS click_daily_countries;
+DROP TABLE IF EXISTS click_daily_devices;
+DROP TABLE IF EXISTS click_daily_referrers;
+DROP TABLE IF EXISTS click_daily;
+DELETE FROM config WHERE key = 'analytics.clicks_pruned_before';
@@ -0,0 +1,26 @@
+-- +goose Up
+-- token is handed to the destination with the redirect so it can report
+-- conversions for the click; clicks recorded without one keep NULL.
+ALTER TABLE clicks ADD COLUMN token TEXT;
+CREATE UNIQUE INDEX idx_clicks_token ON clicks(token) WHERE token IS NOT NULL;
+
+-- A click converts at most once per goal. channel is copied from the click
+-- so conversions can still be broken down once raw clicks are pruned.
+CREATE TABLE conversions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    link_id INTEGER NOT NULL,
+    click_id INTEGER,
+    goal TEXT NOT NULL,
+    channel TEXT NOT NULL DEFAULT '',
+    timestamp DATETIME NOT NULL,
+    UNIQUE (click_id, goal),
+    FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE,
+    FOREIGN KEY (click_id) REFERENCES clicks(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_conversions_link_timestamp ON conversions(link_id, timestamp);
+
+-- +goose Down
+DROP TABLE IF EXISTS conversions;
+DROP INDEX IF EXISTS idx_clicks_token;
+ALTER TABLE clicks DROP COLUMN token;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- JSON-encoded domain.AnalyticsPrivacy; empty means no restrictions.
+ALTER TABLE links ADD COLUMN privacy TEXT DEFAULT '';
+ALTER TABLE folders ADD COLUMN privacy TEXT DEFAULT '';
+
+-- +goose Down
+ALTER TABLE folders DROP COLUMN privacy;
+ALTER TABLE links DROP COLUMN privacy;
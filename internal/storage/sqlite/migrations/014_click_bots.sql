@@ -0,0 +1,10 @@
+-- +goose Up
+-- Bot class of the client behind a click (see domain.BotClasses); empty for
+-- people. Earlier bot hits were never recorded, so existing clicks are human.
+ALTER TABLE clicks ADD COLUMN bot TEXT DEFAULT '';
+
+CREATE INDEX idx_clicks_link_bot ON clicks(link_id, bot);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_clicks_link_bot;
+ALTER TABLE clicks DROP COLUMN bot;
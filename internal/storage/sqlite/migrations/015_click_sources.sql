@@ -0,0 +1,7 @@
+-- +goose Up
+-- How the short link was opened: qr, direct or api. Existing clicks are
+-- left empty since their source is unknown.
+ALTER TABLE clicks ADD COLUMN source TEXT DEFAULT '';
+
+-- +goose Down
+ALTER TABLE clicks DROP COLUMN source;
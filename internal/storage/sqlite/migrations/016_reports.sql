@@ -0,0 +1,29 @@
+-- +goose Up
+-- Scheduled stats reports. metrics is a JSON array; empty means all of
+-- them. Times are stored in UTC so next_run_at compares as text.
+CREATE TABLE reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    slug TEXT NOT NULL DEFAULT '',
+    folder_id INTEGER,
+    tag TEXT NOT NULL DEFAULT '',
+    cadence TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    metrics TEXT NOT NULL DEFAULT '',
+    format TEXT NOT NULL,
+    delivery TEXT NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    directory TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    next_run_at DATETIME NOT NULL,
+    last_run_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_reports_due ON reports(enabled, next_run_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS reports;
@@ -0,0 +1,253 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+const reportColumns = `id, name, scope, slug, folder_id, tag, cadence, timezone, metrics, format,
+	delivery, webhook_url, directory, enabled, next_run_at, last_run_at, last_error, created_at`
+
+type ReportRepository struct {
+	db *DB
+}
+
+func NewReportRepository(db *DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+func (r *ReportRepository) Create(ctx context.Context, report *domain.Report) (*domain.Report, error) {
+	metricsJSON, err := reportMetricsJSON(report.Metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO reports (name, scope, slug, folder_id, tag, cadence, timezone, metrics, format,
+			delivery, webhook_url, directory, enabled, next_run_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		report.Name,
+		report.Scope,
+		report.Slug,
+		report.FolderID,
+		report.Tag,
+		report.Cadence,
+		report.Timezone,
+		metricsJSON,
+		report.Format,
+		report.Delivery,
+		report.WebhookURL,
+		report.Directory,
+		report.Enabled,
+		report.NextRunAt.UTC(),
+		report.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	report.ID = id
+	return report, nil
+}
+
+func (r *ReportRepository) GetByID(ctx context.Context, id int64) (*domain.Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE id = ?`
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrReportNotFound
+		}
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+
+	return report, nil
+}
+
+func (r *ReportRepository) List(ctx context.Context) ([]*domain.Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports ORDER BY name ASC, id ASC`
+	return r.list(ctx, query)
+}
+
+// ListDue retrieves enabled reports whose next run is at or before now,
+// soonest first.
+func (r *ReportRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports
+		WHERE enabled = 1 AND next_run_at <= ?
+		ORDER BY next_run_at ASC
+		LIMIT ?`
+	return r.list(ctx, query, now.UTC(), limit)
+}
+
+func (r *ReportRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.Report, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []*domain.Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// Update stores a report's definition and schedule.
+func (r *ReportRepository) Update(ctx context.Context, report *domain.Report) error {
+	metricsJSON, err := reportMetricsJSON(report.Metrics)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE reports
+		SET name = ?, cadence = ?, timezone = ?, metrics = ?, format = ?, delivery = ?,
+			webhook_url = ?, directory = ?, enabled = ?, next_run_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		report.Name,
+		report.Cadence,
+		report.Timezone,
+		metricsJSON,
+		report.Format,
+		report.Delivery,
+		report.WebhookURL,
+		report.Directory,
+		report.Enabled,
+		report.NextRunAt.UTC(),
+		report.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update report: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrReportNotFound
+	}
+
+	return nil
+}
+
+// RecordRun stores the outcome of a run. A nil nextRunAt leaves the
+// schedule unchanged, as for runs started by hand.
+func (r *ReportRepository) RecordRun(ctx context.Context, id int64, ranAt time.Time, runErr string, nextRunAt *time.Time) error {
+	query := `UPDATE reports SET last_run_at = ?, last_error = ?, next_run_at = COALESCE(?, next_run_at) WHERE id = ?`
+
+	var next interface{}
+	if nextRunAt != nil {
+		next = nextRunAt.UTC()
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, ranAt.UTC(), runErr, next, id); err != nil {
+		return fmt.Errorf("failed to record report run: %w", err)
+	}
+	return nil
+}
+
+func (r *ReportRepository) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM reports WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete report: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrReportNotFound
+	}
+
+	return nil
+}
+
+func reportMetricsJSON(metrics []string) (string, error) {
+	if len(metrics) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+	return string(data), nil
+}
+
+// scanReport reads a report selected with reportColumns.
+func scanReport(row rowScanner) (*domain.Report, error) {
+	report := &domain.Report{}
+	var folderID sql.NullInt64
+	var lastRunAt sql.NullTime
+	var metricsJSON string
+
+	err := row.Scan(
+		&report.ID,
+		&report.Name,
+		&report.Scope,
+		&report.Slug,
+		&folderID,
+		&report.Tag,
+		&report.Cadence,
+		&report.Timezone,
+		&metricsJSON,
+		&report.Format,
+		&report.Delivery,
+		&report.WebhookURL,
+		&report.Directory,
+		&report.Enabled,
+		&report.NextRunAt,
+		&lastRunAt,
+		&report.LastError,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if folderID.Valid {
+		report.FolderID = &folderID.Int64
+	}
+	if lastRunAt.Valid {
+		report.LastRunAt = &lastRunAt.Time
+	}
+	if metricsJSON != "" {
+		if err := json.Unmarshal([]byte(metricsJSON), &report.Metrics); err != nil {
+			return nil, fmt.Errorf("failed to parse metrics: %w", err)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,342 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// Raw clicks are rolled up per UTC day into click_daily and one table per
+// breakdown. Stats over long ranges read whole days from the rollups and
+// only the partial days at either end from clicks, which also lets raw
+// clicks be pruned without losing totals, referrers, devices or countries.
+// Only human clicks are rolled up; bot clicks exist in raw form alone.
+
+// rollupMinRange is the shortest range read from rollups. Shorter ranges
+// scan the raw clicks, which is cheap and needs no day rounding.
+const rollupMinRange = 7 * 24 * time.Hour
+
+// clicksPrunedBeforeKey is the config key holding the time before which raw
+// clicks have been pruned, in RFC 3339.
+const clicksPrunedBeforeKey = "analytics.clicks_pruned_before"
+
+// pruneBatchSize bounds how many clicks one DELETE removes, so pruning a
+// large backlog does not hold the write lock for long.
+const pruneBatchSize = 5000
+
+// clickSource selects the clicks a stats query aggregates: raw clicks
+// matching rawWhere plus, when rollupWhere is set, rollup rows for the
+// whole days in between.
+type clickSource struct {
+	rawWhere    string
+	rawArgs     []interface{}
+	rollupWhere string
+	rollupArgs  []interface{}
+}
+
+// linkScopeClause restricts rows with a link_id column to one link, or to
+// the links in the filter's folder and with its tag.
+func linkScopeClause(linkID int64, filter domain.StatsFilter) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if linkID != 0 {
+		conditions = append(conditions, "link_id = ?")
+		args = append(args, linkID)
+	}
+
+	if filter.FolderID != nil || filter.Tag != "" {
+		var scope []string
+		if filter.FolderID != nil {
+			scope = append(scope, "folder_id = ?")
+			args = append(args, *filter.FolderID)
+		}
+		if filter.Tag != "" {
+			scope = append(scope, "tags LIKE ?")
+			args = append(args, "%\""+filter.Tag+"\"%")
+		}
+		conditions = append(conditions, "link_id IN (SELECT id FROM links WHERE "+strings.Join(scope, " AND ")+")")
+	}
+
+	return conditions, args
+}
+
+// source plans where the clicks for linkID and filter are read from.
+// Rollups hold no channel and no bot clicks, so stats filtered by channel
+// or counting bots always read raw clicks, as do callers passing rollups
+// false.
+func (r *ClickRepository) source(ctx context.Context, linkID int64, filter domain.StatsFilter, rollups bool) (clickSource, error) {
+	var src clickSource
+	src.rawWhere, src.rawArgs = clickFilterClause(linkID, filter)
+	if !rollups || filter.Channel != "" || filter.Bots != domain.BotsExclude {
+		return src, nil
+	}
+
+	prunedBefore, err := r.prunedBefore(ctx)
+	if err != nil {
+		return src, err
+	}
+
+	end := time.Now().UTC()
+	if filter.EndDate != nil && filter.EndDate.Before(end) {
+		end = filter.EndDate.UTC()
+	}
+	var start time.Time
+	if filter.StartDate != nil {
+		start = filter.StartDate.UTC()
+		if end.Sub(start) < rollupMinRange && !start.Before(prunedBefore) {
+			return src, nil
+		}
+	}
+
+	// Rollups cover the days [first, last). A partial day at either end is
+	// read raw, unless it has been pruned and only its rollup is left.
+	var first time.Time
+	if filter.StartDate != nil {
+		first = utcDay(start)
+		if first.Before(start) && !first.Before(prunedBefore) {
+			first = first.AddDate(0, 0, 1)
+		}
+	}
+	last := utcDay(end)
+	if next := last.AddDate(0, 0, 1); !end.Add(time.Nanosecond).Before(next) || last.Before(prunedBefore) {
+		last = next
+	}
+	if !first.Before(last) {
+		return src, nil
+	}
+
+	rollupConditions, rollupArgs := linkScopeClause(linkID, filter)
+	rollupConditions = append(rollupConditions, "day < ?")
+	rollupArgs = append(rollupArgs, last.Format("2006-01-02"))
+	if !first.IsZero() {
+		rollupConditions = append(rollupConditions, "day >= ?")
+		rollupArgs = append(rollupArgs, first.Format("2006-01-02"))
+		src.rawWhere += " AND (timestamp < ? OR timestamp >= ?)"
+		src.rawArgs = append(src.rawArgs, first, last)
+	} else {
+		src.rawWhere += " AND timestamp >= ?"
+		src.rawArgs = append(src.rawArgs, last)
+	}
+	src.rollupWhere = strings.Join(rollupConditions, " AND ")
+	src.rollupArgs = rollupArgs
+
+	return src, nil
+}
+
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// perLinkQuery returns a query yielding link_id, clicks and visitors for
+// every link with clicks in src.
+func (src clickSource) perLinkQuery() (string, []interface{}) {
+	query := `SELECT link_id, COUNT(*) AS clicks, COUNT(DISTINCT NULLIF(visitor_id, '')) AS visitors
+		FROM clicks WHERE ` + src.rawWhere + ` GROUP BY link_id`
+	args := append([]interface{}{}, src.rawArgs...)
+
+	if src.rollupWhere != "" {
+		// Visitor IDs change daily, so per-day distinct counts add up.
+		query = `SELECT link_id, SUM(clicks) AS clicks, SUM(visitors) AS visitors FROM (
+			` + query + `
+			UNION ALL
+			SELECT link_id, SUM(clicks), SUM(visitors) FROM click_daily WHERE ` + src.rollupWhere + ` GROUP BY link_id
+		) GROUP BY link_id`
+		args = append(args, src.rollupArgs...)
+	}
+	return query, args
+}
+
+// countQuery returns a query yielding column and clicks for rows matching
+// cond, most clicks first. column must exist in clicks and in table, the
+// rollup of it.
+func (src clickSource) countQuery(column, table, cond string) (string, []interface{}) {
+	query := `SELECT ` + column + `, COUNT(*) AS clicks
+		FROM clicks WHERE ` + src.rawWhere + ` AND ` + cond + ` GROUP BY ` + column
+	args := append([]interface{}{}, src.rawArgs...)
+
+	if src.rollupWhere != "" {
+		query = `SELECT ` + column + `, SUM(clicks) AS clicks FROM (
+			` + query + `
+			UNION ALL
+			SELECT ` + column + `, SUM(clicks) FROM ` + table + ` WHERE ` + src.rollupWhere + ` AND ` + cond + ` GROUP BY ` + column + `
+		) GROUP BY ` + column
+		args = append(args, src.rollupArgs...)
+	}
+	return query + ` ORDER BY clicks DESC`, args
+}
+
+// rollupKey identifies one rollup row.
+type rollupKey struct {
+	linkID int64
+	day    string
+	value  string
+}
+
+// recordRollups adds the human clicks among clicks to the daily rollups
+// within tx. It must run before the clicks themselves are inserted, so a
+// visitor is only counted the first time their ID is seen.
+func recordRollups(ctx context.Context, tx *sql.Tx, clicks []*domain.Click) error {
+	type totals struct{ clicks, visitors int64 }
+	daily := make(map[rollupKey]*totals)
+	referrers := make(map[rollupKey]int64)
+	devices := make(map[rollupKey]int64)
+	countries := make(map[rollupKey]int64)
+
+	seen, err := tx.PrepareContext(ctx, `SELECT EXISTS(SELECT 1 FROM clicks WHERE link_id = ? AND visitor_id = ? AND bot = '')`)
+	if err != nil {
+		return err
+	}
+	defer seen.Close()
+	batchVisitors := make(map[rollupKey]bool)
+
+	for _, click := range clicks {
+		if click.Bot != "" {
+			continue
+		}
+		day := click.Timestamp.UTC().Format("2006-01-02")
+		key := rollupKey{linkID: click.LinkID, day: day}
+
+		t := daily[key]
+		if t == nil {
+			t = &totals{}
+			daily[key] = t
+		}
+		t.clicks++
+
+		if click.VisitorID != "" {
+			visitor := rollupKey{linkID: click.LinkID, value: click.VisitorID}
+			if !batchVisitors[visitor] {
+				batchVisitors[visitor] = true
+				var exists bool
+				if err := seen.QueryRowContext(ctx, click.LinkID, click.VisitorID).Scan(&exists); err != nil {
+					return err
+				}
+				if !exists {
+					t.visitors++
+				}
+			}
+		}
+
+		referrers[rollupKey{click.LinkID, day, click.Referrer}]++
+		devices[rollupKey{click.LinkID, day, click.DeviceType}]++
+		countries[rollupKey{click.LinkID, day, click.Country}]++
+	}
+
+	upsertDaily, err := tx.PrepareContext(ctx, `
+		INSERT INTO click_daily (link_id, day, clicks, visitors) VALUES (?, ?, ?, ?)
+		ON CONFLICT(link_id, day) DO UPDATE SET
+			clicks = clicks + excluded.clicks, visitors = visitors + excluded.visitors`)
+	if err != nil {
+		return err
+	}
+	defer upsertDaily.Close()
+	for key, t := range daily {
+		if _, err := upsertDaily.ExecContext(ctx, key.linkID, key.day, t.clicks, t.visitors); err != nil {
+			return err
+		}
+	}
+
+	breakdowns := []struct {
+		table, column string
+		counts        map[rollupKey]int64
+	}{
+		{"click_daily_referrers", "referrer", referrers},
+		{"click_daily_devices", "device_type", devices},
+		{"click_daily_countries", "country", countries},
+	}
+	for _, b := range breakdowns {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO `+b.table+` (link_id, day, `+b.column+`, clicks) VALUES (?, ?, ?, ?)
+			ON CONFLICT(link_id, day, `+b.column+`) DO UPDATE SET clicks = clicks + excluded.clicks`)
+		if err != nil {
+			return err
+		}
+		for key, n := range b.counts {
+			if _, err := stmt.ExecContext(ctx, key.linkID, key.day, key.value, n); err != nil {
+				stmt.Close()
+				return err
+			}
+		}
+		stmt.Close()
+	}
+
+	return nil
+}
+
+// PruneClicks deletes raw clicks recorded before the given time, keeping
+// their daily rollups, and returns how many were deleted. before should be
+// a UTC midnight so no day is left half pruned.
+func (r *ClickRepository) PruneClicks(ctx context.Context, before time.Time) (int64, error) {
+	before = before.UTC()
+
+	var total int64
+	for {
+		res, err := r.db.ExecContext(ctx,
+			`DELETE FROM clicks WHERE id IN (SELECT id FROM clicks WHERE timestamp < ? LIMIT ?)`,
+			before, pruneBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to prune clicks: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to prune clicks: %w", err)
+		}
+		total += n
+		if n < pruneBatchSize {
+			break
+		}
+	}
+
+	current, err := r.prunedBefore(ctx)
+	if err != nil {
+		return total, err
+	}
+	if !before.After(current) {
+		return total, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO config (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		clicksPrunedBeforeKey, before.Format(time.RFC3339), time.Now())
+	if err != nil {
+		return total, fmt.Errorf("failed to record click pruning: %w", err)
+	}
+	r.pruned, r.prunedLoaded = before, true
+
+	return total, nil
+}
+
+// prunedBefore returns the time before which raw clicks have been pruned,
+// or the zero time if they never were.
+func (r *ClickRepository) prunedBefore(ctx context.Context) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.prunedLoaded {
+		return r.pruned, nil
+	}
+
+	var value string
+	err := r.db.QueryRowContext(ctx, `SELECT value FROM config WHERE key = ?`, clicksPrunedBeforeKey).Scan(&value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("failed to get click pruning time: %w", err)
+	}
+	if value != "" {
+		if r.pruned, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, fmt.Errorf("invalid click pruning time %q: %w", value, err)
+		}
+	}
+	r.prunedLoaded = true
+
+	return r.pruned, nil
+}
@@ -0,0 +1,217 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+// seriesUnit is the bucket size of a click time series.
+type seriesUnit int
+
+const (
+	seriesHour seriesUnit = iota
+	seriesDay
+	seriesMonth
+)
+
+// Longest series returned for an explicit date range; longer ranges keep
+// their most recent buckets.
+const (
+	maxHourBuckets  = 7 * 24
+	maxDayBuckets   = 366
+	maxMonthBuckets = 120
+)
+
+// format is the strftime format producing the bucket label; label must
+// produce the same string in Go.
+func (u seriesUnit) format() string {
+	switch u {
+	case seriesHour:
+		return "%Y-%m-%d %H:00"
+	case seriesMonth:
+		return "%Y-%m"
+	default:
+		return "%Y-%m-%d"
+	}
+}
+
+func (u seriesUnit) label(t time.Time) string {
+	switch u {
+	case seriesHour:
+		return t.Format("2006-01-02 15:00")
+	case seriesMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+func (u seriesUnit) max() int {
+	switch u {
+	case seriesHour:
+		return maxHourBuckets
+	case seriesMonth:
+		return maxMonthBuckets
+	default:
+		return maxDayBuckets
+	}
+}
+
+// truncate returns the start of the bucket containing t, in t's location.
+func (u seriesUnit) truncate(t time.Time) time.Time {
+	switch u {
+	case seriesHour:
+		// Not t.Truncate: zones with half-hour offsets would be cut mid-hour.
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case seriesMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// add moves a bucket start n buckets forward or back.
+func (u seriesUnit) add(t time.Time, n int) time.Time {
+	switch u {
+	case seriesHour:
+		// Absolute hours, so the repeated hour when clocks go back is
+		// visited twice and the skipped one not at all.
+		return t.Add(time.Duration(n) * time.Hour)
+	case seriesMonth:
+		return u.truncate(t.AddDate(0, n, 0))
+	default:
+		return u.truncate(t.AddDate(0, 0, n))
+	}
+}
+
+// seriesRange resolves the range a series covers. The filter's dates are
+// used when set, otherwise the last n buckets up to now.
+func seriesRange(filter domain.StatsFilter, u seriesUnit, n int, now time.Time) (start, end time.Time) {
+	loc := filter.Loc()
+
+	end = now.In(loc)
+	if filter.EndDate != nil && filter.EndDate.Before(end) {
+		end = filter.EndDate.In(loc)
+	}
+
+	if filter.StartDate != nil {
+		start = filter.StartDate.In(loc)
+		n = u.max()
+	} else {
+		start = u.add(u.truncate(end), -(n - 1))
+	}
+
+	if earliest := u.add(u.truncate(end), -(n - 1)); start.Before(earliest) {
+		start = earliest
+	}
+	return start, end
+}
+
+// offsetModifier returns an SQL expression yielding the date modifier that
+// shifts a UTC timestamp into loc. When the offset changes between start
+// and end, such as at a daylight saving transition, it is a CASE over the
+// transitions.
+func offsetModifier(loc *time.Location, start, end time.Time) (string, []interface{}) {
+	modifier := func(t time.Time) string {
+		_, offset := t.In(loc).Zone()
+		return fmt.Sprintf("%+d seconds", offset)
+	}
+
+	var cases []string
+	var args []interface{}
+	for t := start; ; {
+		_, zoneEnd := t.In(loc).ZoneBounds()
+		if zoneEnd.IsZero() || zoneEnd.After(end) {
+			break
+		}
+		cases = append(cases, "WHEN timestamp < ? THEN ?")
+		args = append(args, zoneEnd.UTC(), modifier(t))
+		t = zoneEnd
+	}
+
+	if len(cases) == 0 {
+		return "?", []interface{}{modifier(start)}
+	}
+	return "CASE " + strings.Join(cases, " ") + " ELSE ? END", append(args, modifier(end))
+}
+
+type seriesPoint struct {
+	label    string
+	clicks   int64
+	visitors int64
+}
+
+// clickSeries counts clicks and distinct visitors per bucket in the
+// filter's time zone, with a zero entry for every bucket without clicks.
+// Points are in ascending order.
+func (r *ClickRepository) clickSeries(ctx context.Context, linkID int64, u seriesUnit, n int, filter domain.StatsFilter) ([]seriesPoint, error) {
+	start, end := seriesRange(filter, u, n, time.Now())
+	if end.Before(start) {
+		return nil, nil
+	}
+
+	ranged := filter
+	startUTC, endUTC := start.UTC(), end.UTC()
+	ranged.StartDate, ranged.EndDate = &startUTC, &endUTC
+
+	// Rollup days are UTC dates, so they only fill day and month buckets of
+	// series grouped in UTC.
+	modifier, args := offsetModifier(filter.Loc(), start, end)
+	utc := len(args) == 1 && args[0] == "+0 seconds"
+	src, err := r.source(ctx, linkID, ranged, u != seriesHour && utc)
+	if err != nil {
+		return nil, err
+	}
+	args = append([]interface{}{u.format()}, args...)
+
+	query := `
+		SELECT strftime(?, timestamp, ` + modifier + `) AS bucket, COUNT(*) AS clicks, COUNT(DISTINCT NULLIF(visitor_id, '')) AS visitors
+		FROM clicks
+		WHERE ` + src.rawWhere + `
+		GROUP BY bucket
+	`
+	args = append(args, src.rawArgs...)
+
+	if src.rollupWhere != "" {
+		query = `SELECT bucket, SUM(clicks), SUM(visitors) FROM (
+			` + query + `
+			UNION ALL
+			SELECT strftime(?, day), SUM(clicks), SUM(visitors) FROM click_daily WHERE ` + src.rollupWhere + ` GROUP BY day
+		) GROUP BY bucket`
+		args = append(append(args, u.format()), src.rollupArgs...)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]seriesPoint)
+	for rows.Next() {
+		var p seriesPoint
+		if err := rows.Scan(&p.label, &p.clicks, &p.visitors); err != nil {
+			return nil, err
+		}
+		counts[p.label] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var points []seriesPoint
+	for t := u.truncate(start); !t.After(end); t = u.add(t, 1) {
+		label := u.label(t)
+		if len(points) > 0 && points[len(points)-1].label == label {
+			continue
+		}
+		p := counts[label]
+		p.label = label
+		points = append(points, p)
+	}
+	return points, nil
+}
@@ -0,0 +1,368 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aftaab/trelay/internal/core/domain"
+)
+
+const (
+	webhookColumns  = `id, url, secret, events, enabled, created_at, updated_at`
+	deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status,
+	error, created_at, completed_at`
+)
+
+type WebhookRepository struct {
+	db *DB
+}
+
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	eventsJSON, err := webhookEventsJSON(webhook.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO webhooks (url, secret, events, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		webhook.URL,
+		webhook.Secret,
+		eventsJSON,
+		webhook.Enabled,
+		webhook.CreatedAt.UTC(),
+		webhook.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	webhook.ID = id
+	return webhook, nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id int64) (*domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ?`
+
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]*domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*domain.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, webhook *domain.Webhook) error {
+	eventsJSON, err := webhookEventsJSON(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE webhooks SET url = ?, secret = ?, events = ?, enabled = ?, updated_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query,
+		webhook.URL,
+		webhook.Secret,
+		eventsJSON,
+		webhook.Enabled,
+		webhook.UpdatedAt.UTC(),
+		webhook.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a webhook along with its queued and logged deliveries.
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM webhooks WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Enqueue stores deliveries in one transaction, filling in their IDs.
+// Deliveries to webhooks deleted since they were created are skipped and
+// keep an ID of 0, so one stale webhook cannot fail the whole batch.
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []*domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin webhook enqueue: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, attempts, next_attempt_at,
+			response_status, error, created_at, completed_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE EXISTS (SELECT 1 FROM webhooks WHERE id = ?)
+	`
+
+	for _, d := range deliveries {
+		result, err := tx.ExecContext(ctx, query,
+			d.WebhookID,
+			d.Event,
+			d.Payload,
+			d.Status,
+			d.Attempts,
+			d.NextAttemptAt.UTC(),
+			d.ResponseStatus,
+			d.Error,
+			d.CreatedAt.UTC(),
+			utcOrNil(d.CompletedAt),
+			d.WebhookID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if inserted == 0 {
+			continue
+		}
+		if d.ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ListDueDeliveries retrieves pending deliveries to enabled webhooks whose
+// next attempt is at or before now, oldest first.
+func (r *WebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+			AND webhook_id IN (SELECT id FROM webhooks WHERE enabled = 1)
+		ORDER BY next_attempt_at ASC, id ASC
+		LIMIT ?`
+	return r.listDeliveries(ctx, query, domain.DeliveryPending, now.UTC(), limit)
+}
+
+// ListDeliveries retrieves a webhook's deliveries, newest first.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int64, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = ?`
+	args := []interface{}{webhookID}
+
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	return r.listDeliveries(ctx, query, args...)
+}
+
+func (r *WebhookRepository) listDeliveries(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt.
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, error = ?, completed_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		d.Status,
+		d.Attempts,
+		d.NextAttemptAt.UTC(),
+		d.ResponseStatus,
+		d.Error,
+		utcOrNil(d.CompletedAt),
+		d.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// PruneDeliveries deletes deliveries that finished before the given time
+// and returns how many were deleted. Pending deliveries are kept.
+func (r *WebhookRepository) PruneDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM webhook_deliveries WHERE completed_at IS NOT NULL AND completed_at < ?`
+
+	result, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func webhookEventsJSON(events []domain.WebhookEvent) (string, error) {
+	if len(events) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal webhook events: %w", err)
+	}
+	return string(data), nil
+}
+
+// scanWebhook reads a webhook selected with webhookColumns.
+func scanWebhook(row rowScanner) (*domain.Webhook, error) {
+	webhook := &domain.Webhook{}
+	var eventsJSON string
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		&eventsJSON,
+		&webhook.Enabled,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if eventsJSON != "" {
+		if err := json.Unmarshal([]byte(eventsJSON), &webhook.Events); err != nil {
+			return nil, fmt.Errorf("failed to parse webhook events: %w", err)
+		}
+	}
+
+	return webhook, nil
+}
+
+// scanDelivery reads a delivery selected with deliveryColumns.
+func scanDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	d := &domain.WebhookDelivery{}
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.Event,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.ResponseStatus,
+		&d.Error,
+		&d.CreatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if completedAt.Valid {
+		d.CompletedAt = &completedAt.Time
+	}
+
+	return d, nil
+}
+
+// utcOrNil converts an optional time to UTC for storage.
+func utcOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}